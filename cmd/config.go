@@ -0,0 +1,85 @@
+// Package cmd provides the command-line interface for Buenos Aires.
+package cmd
+
+import (
+	"fmt"
+
+	"buenosaires/internal/config"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+)
+
+// configCmd is the parent command for inspecting and editing the global
+// configuration file.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show, edit, and validate the global configuration",
+	Long:  `Show the current global configuration, update individual fields, or validate a candidate file before replacing the live one.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the current global configuration as TOML",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadGlobalConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		return toml.NewEncoder(cmd.OutOrStdout()).Encode(cfg)
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <path> <value>",
+	Short: "Set a single configuration field",
+	Long:  `Updates one field of the global configuration, addressed by its dotted TOML path, e.g. "buenosaires config set gui.port 9100".`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadGlobalConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		if err := config.SetField(&cfg, args[0], args[1]); err != nil {
+			return err
+		}
+		if err := config.SaveGlobalConfig(cfg); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+		fmt.Printf("Set %s = %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Validate a configuration file",
+	Long:  `Validates the live global configuration, or a candidate file given as an argument, without replacing anything.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var cfg config.GlobalConfig
+		var err error
+		if len(args) == 1 {
+			cfg, err = loadGlobalConfigFile(args[0])
+		} else {
+			cfg, err = config.LoadGlobalConfig()
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := config.Validate(cfg); err != nil {
+			return err
+		}
+		fmt.Println("Configuration is valid.")
+		return nil
+	},
+}
+
+// init registers the config command tree with the root command.
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,345 @@
+// Package cmd provides the command-line interface for Buenos Aires.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"buenosaires/internal/config"
+	"buenosaires/plugins/docker"
+	"buenosaires/plugins/shell"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// ProcessContext carries everything a Plugin needs to process a single
+// changed file from the commit the run loop just picked up.
+type ProcessContext struct {
+	// Ctx is canceled on monitor shutdown; plugins that run long external
+	// commands (e.g. ShellPlugin.Run) should respect it so an in-flight
+	// script is killed instead of outliving the process.
+	Ctx          context.Context
+	Tree         *object.Tree
+	CommitHash   string
+	RepoConfig   config.RepoConfig
+	GlobalConfig config.GlobalConfig
+	// LogDir is this repo's resolved log directory (already created), or
+	// "" if none is configured. Plugins that can stream output live write
+	// their own "<LogDir>/<asset>.log" as they run.
+	LogDir string
+	// RepoPath is the monitored repository's watch.Path. Plugins that need
+	// a stable, repo-specific identifier (e.g. the docker plugin deriving a
+	// default image name) should use this instead of the process's shared
+	// working directory, since multiple repos can be monitored at once.
+	RepoPath string
+}
+
+// Result is the outcome of a Plugin processing one asset, generic enough
+// to cover both script lint/run results and container build/run results.
+// The run loop uses it to update status.AssetStatus and write the combined
+// log file, regardless of which plugin produced it.
+type Result struct {
+	LintOutput  string
+	ExecOutput  string
+	LintPassed  bool
+	RunStatus   string // "success", "failure", or for shell assets any other shell.RunStatus value
+	RunDuration time.Duration
+}
+
+// Plugin is a run-loop dispatch target: something that can claim a changed
+// file from a commit diff and process it. This is distinct from
+// internal/plugins/manager.Manager, which tracks install/enable state -
+// Plugin only gets invoked once the manager confirms its name is enabled.
+type Plugin interface {
+	// Name identifies the plugin for repoConfig.Plugins and the plugin
+	// manager's enabled-state lookup, e.g. "shell" or "docker".
+	Name() string
+	// Matches reports whether this plugin claims a given newly-added file.
+	Matches(change *object.Change) bool
+	// Process runs the plugin against the claimed file and reports the
+	// outcome. An error here means the asset could not be processed at all
+	// (e.g. the file couldn't be read from the tree); lint/run failures are
+	// reported through Result instead.
+	Process(pctx ProcessContext, change *object.Change) (Result, error)
+}
+
+// shellDispatchPlugin adapts shell.ShellPlugin to the Plugin interface,
+// claiming newly added files with an extension shell.SupportedExtensions
+// has an interpreter for (.sh, .bash, .zsh, .ps1, ...).
+type shellDispatchPlugin struct{}
+
+func (shellDispatchPlugin) Name() string { return "shell" }
+
+func (shellDispatchPlugin) Matches(change *object.Change) bool {
+	action, err := change.Action()
+	if err != nil {
+		return false
+	}
+	if action != merkletrie.Insert {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(change.To.Name))
+	for _, supported := range shell.SupportedExtensions() {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func (shellDispatchPlugin) Process(pctx ProcessContext, change *object.Change) (Result, error) {
+	scriptName := change.To.Name
+
+	file, err := pctx.Tree.File(scriptName)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get file from tree: %w", err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get file contents: %w", err)
+	}
+
+	tmpfile, err := os.CreateTemp("", "script-*"+filepath.Ext(scriptName))
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		tmpfile.Close()
+		return Result{}, fmt.Errorf("failed to write to temporary file: %w", err)
+	}
+	tmpfile.Close()
+
+	plugin, err := shell.NewShellPlugin()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to initialize shell plugin: %w", err)
+	}
+	plugin.RepoPath = pctx.RepoPath
+
+	// If a log directory is configured, write this asset's log live as it
+	// runs rather than only after the fact, so the web viewer can tail it.
+	var logWriter io.Writer
+	if pctx.LogDir != "" {
+		logPath := filepath.Join(pctx.LogDir, fmt.Sprintf("%s.log", filepath.Base(scriptName)))
+		logFile, err := os.Create(logPath) // #nosec G304
+		if err != nil {
+			log.Printf("Failed to open log file for %s: %v", scriptName, err)
+		} else {
+			defer logFile.Close()
+			logWriter = logFile
+		}
+	}
+
+	lintOutput, lintReports, err := plugin.LintAndValidate(tmpfile.Name())
+	lintPassed := err == nil
+	lintSummary := shell.SummarizeLintReports(lintReports)
+	if logWriter != nil {
+		fmt.Fprintf(logWriter, "--- LINT OUTPUT ---\n%s\n", lintOutput)
+	}
+	maxRunTime := time.Duration(pctx.RepoConfig.MaxRunTimeSeconds) * time.Second
+	if err != nil {
+		log.Printf("Script validation failed for %s: %v\n%s", scriptName, err, lintOutput)
+		plugin.UpdateAssetAfterRun(scriptName, tmpfile.Name(), pctx.RepoConfig.User, pctx.CommitHash, lintOutput, "", lintPassed, 0, maxRunTime, lintSummary, "failure")
+		return Result{LintOutput: lintOutput, LintPassed: false, RunStatus: "failure"}, nil
+	}
+	log.Printf("Script validation successful for %s:\n%s", scriptName, lintOutput)
+
+	runCtx := pctx.Ctx
+	if runCtx == nil {
+		runCtx = context.Background()
+	}
+	if logWriter != nil {
+		fmt.Fprintln(logWriter, "--- EXECUTION OUTPUT ---")
+	}
+
+	startTime := time.Now()
+	execOutput, err := plugin.Run(runCtx, tmpfile.Name(), shell.RunOptions{
+		AllowSudo:   pctx.RepoConfig.AllowSudo,
+		Sandbox:     pctx.RepoConfig.Sandbox,
+		MaxRunTime:  maxRunTime,
+		LogWriter:   logWriter,
+		LintReports: lintReports,
+		MinSeverity: pctx.RepoConfig.MinLintSeverity,
+	})
+	runDuration := time.Since(startTime)
+	runStatus := shell.RunStatus(err)
+	if err != nil {
+		log.Printf("Failed to execute script %s: %v", scriptName, err)
+	}
+	plugin.UpdateAssetAfterRun(scriptName, tmpfile.Name(), pctx.RepoConfig.User, pctx.CommitHash, lintOutput, execOutput, lintPassed, runDuration, maxRunTime, lintSummary, runStatus)
+
+	return Result{
+		LintOutput:  lintOutput,
+		ExecOutput:  execOutput,
+		LintPassed:  lintPassed,
+		RunStatus:   runStatus,
+		RunDuration: runDuration,
+	}, nil
+}
+
+// dockerDispatchPlugin adapts docker.DockerPlugin to the Plugin interface,
+// claiming newly added Dockerfile/Containerfile files.
+type dockerDispatchPlugin struct{}
+
+func (dockerDispatchPlugin) Name() string { return "docker" }
+
+func (dockerDispatchPlugin) Matches(change *object.Change) bool {
+	action, err := change.Action()
+	if err != nil {
+		return false
+	}
+	base := change.To.Name
+	if idx := strings.LastIndex(base, "/"); idx >= 0 {
+		base = base[idx+1:]
+	}
+	return action == merkletrie.Insert && (base == "Dockerfile" || base == "Containerfile")
+}
+
+func (dockerDispatchPlugin) Process(pctx ProcessContext, change *object.Change) (Result, error) {
+	containerFileName := change.To.Name
+
+	file, err := pctx.Tree.File(containerFileName)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get file from tree: %w", err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get file contents: %w", err)
+	}
+
+	tmpfile, err := os.CreateTemp("", "Dockerfile-*")
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		tmpfile.Close()
+		return Result{}, fmt.Errorf("failed to write to temporary file: %w", err)
+	}
+	tmpfile.Close()
+
+	plugin := docker.DockerPlugin{}
+	lintOutput, err := plugin.LintAndValidate(tmpfile.Name())
+	lintPassed := err == nil
+	if err != nil {
+		log.Printf("Container file validation failed for %s: %v\n%s", containerFileName, err, lintOutput)
+		return Result{LintOutput: lintOutput, LintPassed: false, RunStatus: "failure"}, nil
+	}
+	log.Printf("Container file validation successful for %s:\n%s", containerFileName, lintOutput)
+
+	imageName, imageTag := imageNameAndTag(pctx.RepoConfig, pctx.RepoPath, containerFileName, pctx.CommitHash)
+
+	startTime := time.Now()
+	execOutput, err := plugin.Run(tmpfile.Name(), imageName, imageTag, false, docker.BuildOptions{})
+	runStatus := "success"
+	if err != nil {
+		log.Printf("Failed to build/run container file %s: %v", containerFileName, err)
+		runStatus = "failure"
+	}
+
+	dockerCfg := pctx.RepoConfig.Plugins["docker"]
+	if err == nil && dockerCfg.Push {
+		pushOutput, pushErr := pushImage(pctx.GlobalConfig, dockerCfg, plugin, imageName, imageTag)
+		execOutput += "\n" + pushOutput
+		if pushErr != nil {
+			log.Printf("Failed to push image for %s: %v", containerFileName, pushErr)
+			runStatus = "failure"
+		}
+	}
+	runDuration := time.Since(startTime)
+
+	return Result{
+		LintOutput:  lintOutput,
+		ExecOutput:  execOutput,
+		LintPassed:  lintPassed,
+		RunStatus:   runStatus,
+		RunDuration: runDuration,
+	}, nil
+}
+
+// pushImage resolves the registry credentials a docker PluginConfig opted
+// into and pushes imageName:imageTag, returning the combined push output.
+func pushImage(globalConfig config.GlobalConfig, dockerCfg config.PluginConfig, plugin docker.DockerPlugin, imageName, imageTag string) (string, error) {
+	registryAuth, err := findRegistryAuth(globalConfig.Registries, dockerCfg.Registry)
+	if err != nil {
+		return "", err
+	}
+
+	var password string
+	if registryAuth.Username != "" {
+		password, err = registryAuth.ResolvePassword()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	repository := dockerCfg.Repository
+	if repository == "" {
+		repository = imageName
+	}
+
+	return plugin.Push(repository, imageTag, docker.RegistryAuth{
+		URL:      registryAuth.URL,
+		Username: registryAuth.Username,
+		Password: password,
+		Email:    registryAuth.Email,
+	})
+}
+
+// findRegistryAuth looks up a [[registries]] entry by URL. An empty
+// registryURL matches the first configured registry, mirroring pushing to
+// "the" registry when a repo only has one.
+func findRegistryAuth(registries []config.RegistryAuth, registryURL string) (config.RegistryAuth, error) {
+	if len(registries) == 0 {
+		return config.RegistryAuth{}, fmt.Errorf("no registries configured")
+	}
+	if registryURL == "" {
+		return registries[0], nil
+	}
+	for _, r := range registries {
+		if r.URL == registryURL {
+			return r, nil
+		}
+	}
+	return config.RegistryAuth{}, fmt.Errorf("no registry configured for %q", registryURL)
+}
+
+// imageNameAndTag derives a Docker image name and tag for a Dockerfile
+// change: the name comes from the "docker" plugin's configured alias (or
+// the watched repository's own directory name if none is set), and the tag
+// is the short form of the commit hash that introduced the file.
+func imageNameAndTag(repoConfig config.RepoConfig, repoPath, containerFileName, commitHash string) (string, string) {
+	imageName := repoConfig.Plugins["docker"].Alias
+	if imageName == "" {
+		if repoPath != "" {
+			imageName = strings.ToLower(filepath.Base(repoPath))
+		} else {
+			imageName = "buenosaires"
+		}
+	}
+
+	tag := commitHash
+	if len(tag) > 12 {
+		tag = tag[:12]
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	return imageName, tag
+}
+
+// registeredPlugins lists every Plugin the run loop dispatches changed
+// files to, tried in order for each change.
+var registeredPlugins = []Plugin{
+	shellDispatchPlugin{},
+	dockerDispatchPlugin{},
+}
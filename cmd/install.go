@@ -12,78 +12,179 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// installCmd handles the interactive installation and configuration process.
-// It prompts the user for configuration values and saves them to the global config file.
+// Flags for the non-interactive install paths. installUser, installLogDir,
+// and installBranch default to "", so under --non-interactive an unset flag
+// is reported as missing by config.Validate; an explicitly empty value is
+// treated the same way.
+var (
+	installUser           string
+	installLogDir         string
+	installBranch         string
+	installRepo           string
+	installGUI            bool
+	installGUIPort        int
+	installSyncInterval   int
+	installNonInteractive bool
+	installFromFile       string
+	installStdin          bool
+)
+
+// installCmd handles the installation and configuration process. By default
+// it prompts interactively, but it also supports fully non-interactive use
+// via flags, --from-file, or --stdin so it can run from provisioning
+// scripts, containers, and CI.
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install and configure buenosaires",
 	Long:  `This command installs and configures the buenosaires tool, setting up the necessary configuration file in your home directory.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		reader := bufio.NewReader(os.Stdin)
-
-		// Prompt for username - this will be the default user for running scripts
-		fmt.Print("Enter your username: ")
-		user, _ := reader.ReadString('\n')
-		user = strings.TrimSpace(user)
-
-		// Prompt for log directory - where script execution logs will be saved
-		fmt.Print("Enter the folder to save logs: ")
-		logDir, _ := reader.ReadString('\n')
-		logDir = strings.TrimSpace(logDir)
-
-		// Prompt for branch to monitor - typically "main" or "master"
-		fmt.Print("Enter the branch to monitor (e.g., main): ")
-		branch, _ := reader.ReadString('\n')
-		branch = strings.TrimSpace(branch)
-
-		// Prompt for the repository to scan
-		fmt.Print("Enter the repository to scan (default: https://github.com/gbenselum/buenosaires_test): ")
-		repoURL, _ := reader.ReadString('\n')
-		repoURL = strings.TrimSpace(repoURL)
-		if repoURL == "" {
-			repoURL = "https://github.com/gbenselum/buenosaires_test"
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if installFromFile != "" {
+			cfg, err := loadGlobalConfigFile(installFromFile)
+			if err != nil {
+				return err
+			}
+			return saveAndReport(cfg)
 		}
 
-		// Prompt for Web GUI configuration
-		fmt.Print("Enable Web GUI? (y/n): ")
-		enableGUIStr, _ := reader.ReadString('\n')
-		enableGUI := strings.TrimSpace(strings.ToLower(enableGUIStr)) == "y"
-
-		// If Web GUI is enabled, prompt for port number
-		var port int
-		if enableGUI {
-			fmt.Print("Enter the port for the Web GUI (e.g., 9099): ")
-			portStr, _ := reader.ReadString('\n')
-			portStr = strings.TrimSpace(portStr)
-			if _, err := fmt.Sscanf(portStr, "%d", &port); err != nil {
-				fmt.Println("Invalid port number, defaulting to 9099")
-				port = 9099
+		if installStdin {
+			cfg, err := config.DecodeGlobalConfig(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to parse configuration from stdin: %w", err)
 			}
+			return saveAndReport(cfg)
 		}
 
-		// Create the global configuration object
-		cfg := config.GlobalConfig{
-			User:          user,
-			LogDir:        logDir,
-			Branch:        branch,
-			RepositoryURL: repoURL,
-			GUI: config.GUIConfig{
-				Enabled: enableGUI,
-				Port:    port,
-			},
+		if installNonInteractive {
+			cfg := globalConfigFromFlags()
+			if err := config.Validate(cfg); err != nil {
+				return err
+			}
+			return saveAndReport(cfg)
 		}
 
-		// Save the configuration to the global config file
-		if err := config.SaveGlobalConfig(cfg); err != nil {
-			fmt.Println("Error saving configuration:", err)
-			return
+		return runInteractiveInstall()
+	},
+}
+
+// loadGlobalConfigFile reads and parses a fully-formed GlobalConfig from a
+// TOML file on disk.
+func loadGlobalConfigFile(path string) (config.GlobalConfig, error) {
+	// #nosec G304
+	file, err := os.Open(path)
+	if err != nil {
+		return config.GlobalConfig{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	cfg, err := config.DecodeGlobalConfig(file)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// globalConfigFromFlags builds a GlobalConfig from the --user/--log-dir/...
+// flags, applying the same repository URL default the interactive flow uses.
+func globalConfigFromFlags() config.GlobalConfig {
+	repoURL := installRepo
+	if repoURL == "" {
+		repoURL = "https://github.com/gbenselum/buenosaires_test"
+	}
+	return config.GlobalConfig{
+		User:          installUser,
+		LogDir:        installLogDir,
+		Branch:        installBranch,
+		SyncInterval:  installSyncInterval,
+		RepositoryURL: repoURL,
+		GUI: config.GUIConfig{
+			Enabled: installGUI,
+			Port:    installGUIPort,
+		},
+	}
+}
+
+// saveAndReport saves cfg to the global config file and prints a short
+// confirmation, matching the interactive flow's final message.
+func saveAndReport(cfg config.GlobalConfig) error {
+	if err := config.SaveGlobalConfig(cfg); err != nil {
+		return fmt.Errorf("error saving configuration: %w", err)
+	}
+	fmt.Println("Configuration saved successfully!")
+	return nil
+}
+
+// runInteractiveInstall prompts the user for each configuration value, as
+// the original install command always did.
+func runInteractiveInstall() error {
+	reader := bufio.NewReader(os.Stdin)
+
+	// Prompt for username - this will be the default user for running scripts
+	fmt.Print("Enter your username: ")
+	user, _ := reader.ReadString('\n')
+	user = strings.TrimSpace(user)
+
+	// Prompt for log directory - where script execution logs will be saved
+	fmt.Print("Enter the folder to save logs: ")
+	logDir, _ := reader.ReadString('\n')
+	logDir = strings.TrimSpace(logDir)
+
+	// Prompt for branch to monitor - typically "main" or "master"
+	fmt.Print("Enter the branch to monitor (e.g., main): ")
+	branch, _ := reader.ReadString('\n')
+	branch = strings.TrimSpace(branch)
+
+	// Prompt for the repository to scan
+	fmt.Print("Enter the repository to scan (default: https://github.com/gbenselum/buenosaires_test): ")
+	repoURL, _ := reader.ReadString('\n')
+	repoURL = strings.TrimSpace(repoURL)
+	if repoURL == "" {
+		repoURL = "https://github.com/gbenselum/buenosaires_test"
+	}
+
+	// Prompt for Web GUI configuration
+	fmt.Print("Enable Web GUI? (y/n): ")
+	enableGUIStr, _ := reader.ReadString('\n')
+	enableGUI := strings.TrimSpace(strings.ToLower(enableGUIStr)) == "y"
+
+	// If Web GUI is enabled, prompt for port number
+	var port int
+	if enableGUI {
+		fmt.Print("Enter the port for the Web GUI (e.g., 9099): ")
+		portStr, _ := reader.ReadString('\n')
+		portStr = strings.TrimSpace(portStr)
+		if _, err := fmt.Sscanf(portStr, "%d", &port); err != nil {
+			fmt.Println("Invalid port number, defaulting to 9099")
+			port = 9099
 		}
+	}
 
-		fmt.Println("Configuration saved successfully!")
-	},
+	// Create the global configuration object
+	cfg := config.GlobalConfig{
+		User:          user,
+		LogDir:        logDir,
+		Branch:        branch,
+		RepositoryURL: repoURL,
+		GUI: config.GUIConfig{
+			Enabled: enableGUI,
+			Port:    port,
+		},
+	}
+
+	return saveAndReport(cfg)
 }
 
 // init registers the install command with the root command.
 func init() {
+	installCmd.Flags().StringVar(&installUser, "user", "", "User to run scripts as")
+	installCmd.Flags().StringVar(&installLogDir, "log-dir", "", "Directory to save script execution logs")
+	installCmd.Flags().StringVar(&installBranch, "branch", "", "Branch to monitor (e.g., main)")
+	installCmd.Flags().StringVar(&installRepo, "repo", "", "Repository URL to scan")
+	installCmd.Flags().BoolVar(&installGUI, "gui", false, "Enable the web GUI")
+	installCmd.Flags().IntVar(&installGUIPort, "gui-port", 9099, "Port for the web GUI")
+	installCmd.Flags().IntVar(&installSyncInterval, "sync-interval", 0, "Seconds between repository syncs (0 for the default)")
+	installCmd.Flags().BoolVar(&installNonInteractive, "non-interactive", false, "Fail instead of prompting for missing values")
+	installCmd.Flags().StringVar(&installFromFile, "from-file", "", "Load a complete configuration from a TOML file instead of prompting")
+	installCmd.Flags().BoolVar(&installStdin, "stdin", false, "Read a complete configuration as TOML from standard input")
+
 	rootCmd.AddCommand(installCmd)
-}
\ No newline at end of file
+}
@@ -0,0 +1,205 @@
+// Package cmd provides the command-line interface for Buenos Aires.
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"buenosaires/internal/plugins/manager"
+
+	"github.com/spf13/cobra"
+)
+
+// pluginCmd is the parent command for managing the plugin lifecycle:
+// install, enable, disable, list, inspect, remove and set.
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage buenosaires plugins",
+	Long:  `Install, enable, disable, inspect and remove buenosaires plugins.`,
+}
+
+var pluginInstallAlias string
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <ref>",
+	Short: "Install a plugin",
+	Long:  `Registers a plugin in the local registry. The plugin stays disabled until "plugin enable" is run.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manager.NewManager()
+		if err != nil {
+			return err
+		}
+		entry, err := m.Install(args[0], manager.InstallOptions{Alias: pluginInstallAlias})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed plugin %q (disabled)\n", entry.Name)
+		if len(entry.Privileges) > 0 {
+			fmt.Printf("Requests privileges: %s\n", strings.Join(entry.Privileges, ", "))
+			fmt.Println("Run `buenosaires plugin enable` to review and grant them.")
+		}
+		return nil
+	},
+}
+
+var pluginEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Enable an installed plugin",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manager.NewManager()
+		if err != nil {
+			return err
+		}
+		entry, err := m.Inspect(args[0])
+		if err != nil {
+			return err
+		}
+		if len(entry.Privileges) > 0 {
+			fmt.Printf("Plugin %q requests: %s\n", entry.Name, strings.Join(entry.Privileges, ", "))
+		}
+		if err := m.Enable(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Enabled plugin %q\n", args[0])
+		return nil
+	},
+}
+
+var pluginDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Disable an installed plugin",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manager.NewManager()
+		if err != nil {
+			return err
+		}
+		if err := m.Disable(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Disabled plugin %q\n", args[0])
+		return nil
+	},
+}
+
+var pluginLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List installed plugins",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manager.NewManager()
+		if err != nil {
+			return err
+		}
+		entries, err := m.List()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("No plugins installed.")
+			return nil
+		}
+		for _, entry := range entries {
+			status := "disabled"
+			if entry.Enabled {
+				status = "enabled"
+			}
+			fmt.Printf("%s\t%s\t%s\n", entry.Name, entry.Ref, status)
+		}
+		return nil
+	},
+}
+
+var pluginInspectCmd = &cobra.Command{
+	Use:   "inspect <name>",
+	Short: "Show detailed information about a plugin",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manager.NewManager()
+		if err != nil {
+			return err
+		}
+		entry, err := m.Inspect(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Name:        %s\n", entry.Name)
+		fmt.Printf("Ref:         %s\n", entry.Ref)
+		fmt.Printf("Enabled:     %t\n", entry.Enabled)
+		fmt.Printf("Privileges:  %s\n", strings.Join(entry.Privileges, ", "))
+		fmt.Printf("InstalledAt: %s\n", entry.InstalledAt)
+		if len(entry.Config) > 0 {
+			keys := make([]string, 0, len(entry.Config))
+			for k := range entry.Config {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			fmt.Println("Config:")
+			for _, k := range keys {
+				fmt.Printf("  %s=%s\n", k, entry.Config[k])
+			}
+		}
+		return nil
+	},
+}
+
+var pluginRmForce bool
+
+var pluginRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove an installed plugin",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manager.NewManager()
+		if err != nil {
+			return err
+		}
+		if err := m.Remove(args[0], pluginRmForce); err != nil {
+			return err
+		}
+		fmt.Printf("Removed plugin %q\n", args[0])
+		return nil
+	},
+}
+
+var pluginSetCmd = &cobra.Command{
+	Use:   "set <name> <key>=<value> [<key>=<value> ...]",
+	Short: "Set configuration overrides for a plugin",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manager.NewManager()
+		if err != nil {
+			return err
+		}
+		overrides := make(map[string]string)
+		for _, kv := range args[1:] {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid key=value pair: %s", kv)
+			}
+			overrides[parts[0]] = parts[1]
+		}
+		if err := m.Set(args[0], overrides); err != nil {
+			return err
+		}
+		fmt.Printf("Updated plugin %q\n", args[0])
+		return nil
+	},
+}
+
+// init registers the plugin command tree with the root command.
+func init() {
+	pluginInstallCmd.Flags().StringVar(&pluginInstallAlias, "alias", "", "Alias to register the plugin under")
+	pluginRmCmd.Flags().BoolVar(&pluginRmForce, "force", false, "Remove the plugin even if it is enabled")
+
+	pluginCmd.AddCommand(pluginInstallCmd)
+	pluginCmd.AddCommand(pluginEnableCmd)
+	pluginCmd.AddCommand(pluginDisableCmd)
+	pluginCmd.AddCommand(pluginLsCmd)
+	pluginCmd.AddCommand(pluginInspectCmd)
+	pluginCmd.AddCommand(pluginRmCmd)
+	pluginCmd.AddCommand(pluginSetCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
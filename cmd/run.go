@@ -2,31 +2,38 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"buenosaires/internal/config"
+	"buenosaires/internal/plugins/manager"
 	"buenosaires/internal/status"
 	"buenosaires/internal/web"
-	"buenosaires/plugins/shell"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/utils/merkletrie"
 	"github.com/spf13/cobra"
 )
 
-// runCmd implements the main monitoring loop that watches a Git repository
-// for new shell scripts and executes them after validation.
+// defaultMaxConcurrentRuns bounds how many scripts/builds run at once across
+// every monitored repo when max_concurrent_runs isn't set.
+const defaultMaxConcurrentRuns = 4
+
+// runCmd implements the main monitoring loop that watches one or more Git
+// repositories for new assets (shell scripts, Dockerfiles, ...) and
+// processes them after validation.
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run the buenosaires monitor",
-	Long:  `This command starts the buenosaires monitor, which watches a repository for new shell scripts and executes them.`,
+	Long:  `This command starts the buenosaires monitor, which watches one or more repositories for new assets and processes them.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Load the global configuration from ~/.buenosaires/config.toml
 		globalConfig, err := config.LoadGlobalConfig()
@@ -34,234 +41,359 @@ var runCmd = &cobra.Command{
 			log.Fatalf("Failed to load global config: %v", err)
 		}
 
-		// Load the status file that tracks script execution history
-		status, err := status.LoadStatus(".")
-		if err != nil {
-			log.Fatalf("Failed to load status file: %v", err)
+		repoWatches := globalConfig.Repos
+		if len(repoWatches) == 0 {
+			// No [[repos]] configured: preserve the original single-repo
+			// behavior of monitoring the current directory. Resolve "." to
+			// an absolute path since status.getStatusFilePath rejects "."
+			// as a directory-traversal guard.
+			cwd, err := filepath.Abs(".")
+			if err != nil {
+				log.Fatalf("Failed to resolve working directory: %v", err)
+			}
+			repoWatches = []config.RepoWatch{{
+				Path:         cwd,
+				Branch:       globalConfig.Branch,
+				LogDir:       globalConfig.LogDir,
+				SyncInterval: globalConfig.SyncInterval,
+			}}
+		}
+
+		// ctx is canceled on SIGINT/SIGTERM; every monitor goroutine checks
+		// it between assets and at the top of each sync cycle and starts no
+		// new asset once it is canceled. ctx is also handed to the plugins:
+		// an in-flight shell script is killed on cancellation, while an
+		// in-flight docker build runs to completion before its goroutine
+		// returns.
+		ctx, cancel := context.WithCancel(context.Background())
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigCh
+			log.Println("Shutdown signal received, draining in-flight repos...")
+			cancel()
+		}()
+
+		// execPool bounds the number of scripts/builds running at once
+		// across all monitored repos, so many repos pushing at the same
+		// time can't exhaust host resources.
+		maxConcurrentRuns := globalConfig.MaxConcurrentRuns
+		if maxConcurrentRuns <= 0 {
+			maxConcurrentRuns = defaultMaxConcurrentRuns
+		}
+		execPool := make(chan struct{}, maxConcurrentRuns)
+
+		// webhookSyncNow is what the web server's webhook receiver signals;
+		// it fans out to every repo's own syncNow channel below, since a
+		// single receiver can't tell which repo a push belongs to without
+		// parsing payloads we don't otherwise need.
+		webhookSyncNow := make(chan struct{}, 1)
+		repoSyncChannels := make([]chan struct{}, len(repoWatches))
+		for i := range repoSyncChannels {
+			repoSyncChannels[i] = make(chan struct{}, 1)
 		}
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-webhookSyncNow:
+					for _, ch := range repoSyncChannels {
+						select {
+						case ch <- struct{}{}:
+						default:
+						}
+					}
+				}
+			}
+		}()
 
-		// Start the web server if enabled
 		if globalConfig.GUI.Enabled {
+			// The webhook secret is necessarily singular for one HTTP
+			// receiver; take it from the first monitored repo's config.
+			var webhookSecret string
+			if cfg, err := config.LoadRepoConfig(repoWatches[0].Path); err == nil {
+				webhookSecret = cfg.WebhookSecret
+			}
+
+			logDirs := make([]string, 0, len(repoWatches))
+			repoPaths := make([]string, 0, len(repoWatches))
+			for _, watch := range repoWatches {
+				logDir := watch.LogDir
+				if logDir == "" {
+					logDir = globalConfig.LogDir
+				}
+				if logDir != "" {
+					logDirs = append(logDirs, logDir)
+					repoPaths = append(repoPaths, watch.Path)
+				}
+			}
+
 			addr := fmt.Sprintf(":%d", globalConfig.GUI.Port)
-			go web.StartServer(addr, globalConfig.LogDir)
+			go web.StartServer(addr, logDirs, repoPaths, webhookSyncNow, webhookSecret)
 		}
 
-		// Open the Git repository in the current directory
-		repo, err := git.PlainOpen(".")
-		if err != nil {
-			log.Fatalf("Failed to open repository: %v", err)
+		var wg sync.WaitGroup
+		for i, watch := range repoWatches {
+			wg.Add(1)
+			go func(watch config.RepoWatch, syncNow <-chan struct{}) {
+				defer wg.Done()
+				monitorRepo(ctx, watch, globalConfig, execPool, syncNow)
+			}(watch, repoSyncChannels[i])
 		}
+		wg.Wait()
+	},
+}
 
-		// Get the reference for the branch to monitor
-		branchRefName := plumbing.NewBranchReferenceName(globalConfig.Branch)
-		branchRef, err := repo.Reference(branchRefName, true)
-		if err != nil {
-			log.Fatalf("Failed to get branch reference: %v", err)
-		}
+// monitorRepo watches a single repository's branch for new commits,
+// dispatching any matching assets to the registered plugins. It keeps its
+// own commit cursor and its own status.Status, persisted under
+// <watch.Path>/.buenosaires/status.json, so multiple repos never share
+// state. It returns once ctx is canceled and the current sync cycle (if
+// any) has finished.
+func monitorRepo(ctx context.Context, watch config.RepoWatch, globalConfig config.GlobalConfig, execPool chan struct{}, syncNow <-chan struct{}) {
+	branch := watch.Branch
+	if branch == "" {
+		branch = globalConfig.Branch
+	}
 
-		var lastCommitHash plumbing.Hash
-		if branchRef != nil {
-			lastCommitHash = branchRef.Hash()
-		}
+	// Load the status file that tracks this repo's execution history
+	repoStatus, err := status.LoadStatus(watch.Path)
+	if err != nil {
+		log.Printf("[%s] Failed to load status file: %v", watch.Path, err)
+		return
+	}
+
+	// Open the Git repository
+	repo, err := git.PlainOpen(watch.Path)
+	if err != nil {
+		log.Printf("[%s] Failed to open repository: %v", watch.Path, err)
+		return
+	}
+
+	// Get the reference for the branch to monitor
+	branchRefName := plumbing.NewBranchReferenceName(branch)
+	branchRef, err := repo.Reference(branchRefName, true)
+	if err != nil {
+		log.Printf("[%s] Failed to get branch reference: %v", watch.Path, err)
+		return
+	}
+
+	var lastCommitHash plumbing.Hash
+	if branchRef != nil {
+		lastCommitHash = branchRef.Hash()
+	}
 
-		log.Printf("Starting to monitor branch '%s'", globalConfig.Branch)
+	log.Printf("[%s] Starting to monitor branch '%s'", watch.Path, branch)
 
-		// Main monitoring loop - polls the repository every 10 seconds
-		for {
-			syncInterval := time.Duration(globalConfig.SyncInterval) * time.Second
+	for {
+		if ctx.Err() != nil {
+			log.Printf("[%s] Shutting down", watch.Path)
+			return
+		}
+
+		syncInterval := time.Duration(watch.SyncInterval) * time.Second
+		if watch.SyncInterval == 0 {
+			syncInterval = time.Duration(globalConfig.SyncInterval) * time.Second
 			if globalConfig.SyncInterval == 0 {
 				syncInterval = 180 * time.Second
 			}
+		}
 
-			// Fetch the latest changes from the remote
-			err := repo.Fetch(&git.FetchOptions{})
-			if err != nil && err != git.NoErrAlreadyUpToDate {
-				log.Printf("Failed to fetch from remote: %v", err)
-				time.Sleep(syncInterval)
-				continue
-			}
+		// Fetch the latest changes from the remote
+		err := repo.Fetch(&git.FetchOptions{})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			log.Printf("[%s] Failed to fetch from remote: %v", watch.Path, err)
+			waitForNextSync(ctx, syncNow, syncInterval)
+			continue
+		}
 
-			// Get the latest commit on the branch
-			branchRef, err := repo.Reference(branchRefName, true)
-			if err != nil {
-				log.Printf("Failed to get branch reference: %v", err)
-				time.Sleep(syncInterval)
-				continue
-			}
+		// Get the latest commit on the branch
+		branchRef, err := repo.Reference(branchRefName, true)
+		if err != nil {
+			log.Printf("[%s] Failed to get branch reference: %v", watch.Path, err)
+			waitForNextSync(ctx, syncNow, syncInterval)
+			continue
+		}
 
-			latestCommitHash := branchRef.Hash()
+		latestCommitHash := branchRef.Hash()
 
-			// Process new commits if the hash has changed
-			if latestCommitHash != lastCommitHash {
-				log.Printf("New commit detected: %s", latestCommitHash.String())
+		// Process new commits if the hash has changed
+		if latestCommitHash != lastCommitHash {
+			log.Printf("[%s] New commit detected: %s", watch.Path, latestCommitHash.String())
+			processCommit(ctx, watch, globalConfig, repo, repoStatus, execPool, lastCommitHash, latestCommitHash)
+			lastCommitHash = latestCommitHash
+		}
 
-				// Get the commit objects
-				latestCommit, err := repo.CommitObject(latestCommitHash)
-				if err != nil {
-					log.Printf("Failed to get latest commit object: %v", err)
-					lastCommitHash = latestCommitHash
-					continue
-				}
+		waitForNextSync(ctx, syncNow, syncInterval)
+	}
+}
 
-				var lastCommit *object.Commit
-				if lastCommitHash != (plumbing.Hash{}) {
-					lastCommit, err = repo.CommitObject(lastCommitHash)
-					if err != nil {
-						log.Printf("Failed to get last commit object: %v", err)
-						lastCommitHash = latestCommitHash
-						continue
-					}
-				}
+// processCommit diffs lastCommitHash against latestCommitHash and dispatches
+// every matching asset to the registered plugins, bounding actual
+// script/build execution to execPool's capacity.
+func processCommit(ctx context.Context, watch config.RepoWatch, globalConfig config.GlobalConfig, repo *git.Repository, repoStatus *status.Status, execPool chan struct{}, lastCommitHash, latestCommitHash plumbing.Hash) {
+	latestCommit, err := repo.CommitObject(latestCommitHash)
+	if err != nil {
+		log.Printf("[%s] Failed to get latest commit object: %v", watch.Path, err)
+		return
+	}
 
-				// Get the trees for both commits
-				latestTree, err := latestCommit.Tree()
-				if err != nil {
-					log.Printf("Failed to get latest commit tree: %v", err)
-					lastCommitHash = latestCommitHash
-					continue
-				}
+	var lastCommit *object.Commit
+	if lastCommitHash != (plumbing.Hash{}) {
+		lastCommit, err = repo.CommitObject(lastCommitHash)
+		if err != nil {
+			log.Printf("[%s] Failed to get last commit object: %v", watch.Path, err)
+			return
+		}
+	}
 
-				var lastTree *object.Tree
-				if lastCommit != nil {
-					lastTree, err = lastCommit.Tree()
-					if err != nil {
-						log.Printf("Failed to get last commit tree: %v", err)
-						lastCommitHash = latestCommitHash
-						continue
-					}
-				}
+	latestTree, err := latestCommit.Tree()
+	if err != nil {
+		log.Printf("[%s] Failed to get latest commit tree: %v", watch.Path, err)
+		return
+	}
 
-				// Compare the trees to find new files
-				changes, err := object.DiffTree(lastTree, latestTree)
-				if err != nil {
-					log.Printf("Failed to diff trees: %v", err)
-					lastCommitHash = latestCommitHash
-					continue
-				}
+	var lastTree *object.Tree
+	if lastCommit != nil {
+		lastTree, err = lastCommit.Tree()
+		if err != nil {
+			log.Printf("[%s] Failed to get last commit tree: %v", watch.Path, err)
+			return
+		}
+	}
 
-				// Load repo-specific config
-				repoConfig, err := config.LoadRepoConfig(".")
-				if err != nil {
-					log.Printf("Failed to load repo config: %v", err)
-				}
+	changes, err := object.DiffTree(lastTree, latestTree)
+	if err != nil {
+		log.Printf("[%s] Failed to diff trees: %v", watch.Path, err)
+		return
+	}
 
-				// Check for new .sh files
-				if repoConfig.Plugins["shell"] {
-					for _, change := range changes {
-						if isNewShellScript(change) {
-							scriptName := change.To.Name
-							if s, ok := status.Scripts[scriptName]; ok && s.OverallStatus == "success" {
-								log.Printf("Script %s already processed successfully, skipping.", scriptName)
-								continue
-							}
-
-							log.Printf("New shell script found: %s", scriptName)
-							// Initialize the script status as pending
-							status.UpdateScriptStatus(scriptName, "pending", "skipped", "pending", "pending")
-							status.SaveStatus(".")
-
-							// Retrieve the file content from the Git tree
-							file, err := latestTree.File(scriptName)
-							if err != nil {
-								log.Printf("Failed to get file from tree: %v", err)
-								continue
-							}
-							content, err := file.Contents()
-							if err != nil {
-								log.Printf("Failed to get file contents: %v", err)
-								continue
-							}
-
-							// Create a temporary file to store the script for validation and execution
-							tmpfile, err := os.CreateTemp("", "script-*.sh")
-							if err != nil {
-								log.Printf("Failed to create temporary file: %v", err)
-								continue
-							}
-							defer os.Remove(tmpfile.Name())
-
-							if _, err := tmpfile.Write([]byte(content)); err != nil {
-								log.Printf("Failed to write to temporary file: %v", err)
-								tmpfile.Close()
-								continue
-							}
-							tmpfile.Close()
-
-							// Validate the script using shellcheck and syntax checking
-							plugin := shell.ShellPlugin{}
-							lintOutput, err := plugin.LintAndValidate(tmpfile.Name())
-							lintPassed := err == nil
-							if err != nil {
-								log.Printf("Script validation failed for %s: %v\n%s", scriptName, err, lintOutput)
-								status.UpdateScriptStatus(scriptName, "failure", "skipped", "pending", "failure")
-								status.SaveStatus(".")
-								plugin.UpdateAssetAfterRun(scriptName, repoConfig.User, latestCommitHash.String(), lintOutput, lintPassed, 0, "failure")
-								continue // Skip execution of invalid scripts
-							}
-							log.Printf("Script validation successful for %s:\n%s", scriptName, lintOutput)
-							status.UpdateScriptStatus(scriptName, "success", "skipped", "pending", "pending")
-							status.SaveStatus(".")
-
-							// Execute the script
-							startTime := time.Now()
-							execOutput, err := plugin.Run(tmpfile.Name(), repoConfig.AllowSudo)
-							runDuration := time.Since(startTime)
-							runStatus := "success"
-							if err != nil {
-								log.Printf("Failed to execute script %s: %v", scriptName, err)
-								status.UpdateScriptStatus(scriptName, "success", "skipped", "failure", "failure")
-								status.SaveStatus(".")
-								runStatus = "failure"
-							} else {
-								status.UpdateScriptStatus(scriptName, "success", "skipped", "success", "success")
-								status.SaveStatus(".")
-							}
-							plugin.UpdateAssetAfterRun(scriptName, repoConfig.User, latestCommitHash.String(), execOutput, lintPassed, runDuration, runStatus)
-
-							// Write the combined lint and execution output to a log file
-							logDir := repoConfig.LogDir
-							if logDir == "" {
-								logDir = globalConfig.LogDir
-							}
-							if logDir != "" {
-								if _, err := os.Stat(logDir); os.IsNotExist(err) {
-									os.MkdirAll(logDir, 0755)
-								}
-								logFile := filepath.Join(logDir, fmt.Sprintf("%s.log", filepath.Base(scriptName)))
-								logContent := fmt.Sprintf("--- LINT OUTPUT ---\n%s\n--- EXECUTION OUTPUT ---\n%s", lintOutput, execOutput)
-								err := os.WriteFile(logFile, []byte(logContent), 0644)
-								if err != nil {
-									log.Printf("Failed to write log file: %v", err)
-								}
-							}
-						}
-					}
-				}
+	repoConfig, err := config.LoadRepoConfig(watch.Path)
+	if err != nil {
+		log.Printf("[%s] Failed to load repo config: %v", watch.Path, err)
+	}
 
-			lastCommitHash = latestCommitHash
+	// A plugin only runs if the repo opts in *and* it has been enabled via
+	// `buenosaires plugin enable` - installing a plugin no longer implies
+	// it is allowed to run.
+	pluginManager, err := manager.NewManager()
+	if err != nil {
+		log.Printf("[%s] Failed to load plugin manager: %v", watch.Path, err)
+	}
+
+	// If the repo requires signed commits, verify latestCommit once up
+	// front rather than per asset - every asset from this commit shares
+	// the same signature outcome.
+	signatureStatus := "skipped"
+	if repoConfig.RequireSignedCommits {
+		var sigErr error
+		signatureStatus, sigErr = verifyCommitSignature(latestCommit, repoConfig.AllowedSigners)
+		if sigErr != nil {
+			log.Printf("[%s] Commit signature verification: %v", watch.Path, sigErr)
+		}
+	}
+
+	logDir := watch.LogDir
+	if logDir == "" {
+		logDir = repoConfig.LogDir
+	}
+	if logDir == "" {
+		logDir = globalConfig.LogDir
+	}
+	if logDir != "" {
+		if _, err := os.Stat(logDir); os.IsNotExist(err) {
+			os.MkdirAll(logDir, 0755)
 		}
+	}
 
-		// Wait before polling again
-		if globalConfig.SyncInterval == 0 {
-			time.Sleep(180 * time.Second)
-		} else {
-			time.Sleep(time.Duration(globalConfig.SyncInterval) * time.Second)
+	pctx := ProcessContext{Ctx: ctx, Tree: latestTree, CommitHash: latestCommitHash.String(), RepoConfig: repoConfig, GlobalConfig: globalConfig, LogDir: logDir, RepoPath: watch.Path}
+	for _, plugin := range registeredPlugins {
+		pluginCfg, ok := repoConfig.Plugins[plugin.Name()]
+		if !ok || !pluginCfg.Enabled || pluginManager == nil || !pluginManager.IsEnabled(plugin.Name()) {
+			continue
 		}
+
+		for _, change := range changes {
+			if ctx.Err() != nil {
+				return
+			}
+			if !plugin.Matches(change) {
+				continue
+			}
+
+			assetName := change.To.Name
+			if s, ok := repoStatus.Scripts[assetName]; ok && s.OverallStatus == "success" {
+				log.Printf("[%s] Asset %s already processed successfully, skipping.", watch.Path, assetName)
+				continue
+			}
+
+			log.Printf("[%s] New %s asset found: %s", watch.Path, plugin.Name(), assetName)
+			repoStatus.UpdateScriptStatus(assetName, "pending", "skipped", "pending", "pending", signatureStatus)
+			repoStatus.SaveStatus(watch.Path)
+
+			if repoConfig.RequireSignedCommits && signatureStatus != "success" {
+				log.Printf("[%s] Refusing to run %s: commit %s failed signature verification", watch.Path, assetName, latestCommitHash)
+				repoStatus.UpdateScriptStatus(assetName, "skipped", "skipped", "failure", "failure", signatureStatus)
+				repoStatus.SaveStatus(watch.Path)
+				continue
+			}
+
+			// Acquire a slot in the shared execution pool before running
+			// the plugin, so this repo's scripts/builds count against the
+			// same maxConcurrentRuns limit as every other monitored repo's.
+			select {
+			case execPool <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			result, err := plugin.Process(pctx, change)
+			<-execPool
+
+			if err != nil {
+				log.Printf("[%s] Plugin %s failed to process %s: %v", watch.Path, plugin.Name(), assetName, err)
+				continue
+			}
+
+			lintStatus := "failure"
+			if result.LintPassed {
+				lintStatus = "success"
+			}
+			overallStatus := "failure"
+			if result.LintPassed && result.RunStatus == "success" {
+				overallStatus = "success"
+			}
+			repoStatus.UpdateScriptStatus(assetName, lintStatus, "skipped", result.RunStatus, overallStatus, signatureStatus)
+			repoStatus.SaveStatus(watch.Path)
+
+			// Write the combined lint and execution output to a log file.
+			// Plugins that stream their own output live (e.g. ShellPlugin,
+			// via pctx.LogDir) will have already written this same content
+			// incrementally; this is a harmless final rewrite for them and
+			// the only log write for plugins that don't stream.
+			if logDir != "" {
+				logFile := filepath.Join(logDir, fmt.Sprintf("%s.log", filepath.Base(assetName)))
+				logContent := fmt.Sprintf("--- LINT OUTPUT ---\n%s\n--- EXECUTION OUTPUT ---\n%s", result.LintOutput, result.ExecOutput)
+				if err := os.WriteFile(logFile, []byte(logContent), 0644); err != nil {
+					log.Printf("[%s] Failed to write log file: %v", watch.Path, err)
+				}
+			}
 		}
-	},
+	}
 }
 
-// isNewShellScript checks if a Git change represents a newly added shell script.
-// It returns true only if the change is an insert operation and the file has a .sh extension.
-func isNewShellScript(change *object.Change) bool {
-	action, err := change.Action()
-	if err != nil {
-		return false
+// waitForNextSync blocks until ctx is canceled, a verified webhook signals
+// an immediate sync on syncNow, or interval elapses - whichever comes
+// first.
+func waitForNextSync(ctx context.Context, syncNow <-chan struct{}, interval time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-syncNow:
+	case <-time.After(interval):
 	}
-	return action == merkletrie.Insert && strings.HasSuffix(change.To.Name, ".sh")
 }
 
 // init registers the run command with the root command.
 func init() {
 	rootCmd.AddCommand(runCmd)
-}
\ No newline at end of file
+}
@@ -0,0 +1,106 @@
+// Package cmd provides the command-line interface for Buenos Aires.
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"buenosaires/internal/status"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// sshSignatureHeader is the first line of a commit signed via
+// `git commit -S` with gpg.format=ssh (i.e. signed with ssh-keygen -Y sign
+// rather than gpg), as opposed to an armored PGP signature.
+const sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+
+// verifyCommitSignature checks commit's signature against allowedSigners,
+// returning a status.Status* value suitable for AssetStatus.SignatureStatus:
+// status.StatusSuccess if the signature verifies against a trusted key,
+// status.StatusFailure if it's missing, unreadable, or untrusted. An error
+// is also returned describing why verification didn't succeed, for logging.
+//
+// Both GPG/PGP commit signatures (allowedSigners is an armored PGP keyring)
+// and SSH commit signatures (allowedSigners is an SSH allowed_signers file)
+// are supported; which one to expect is inferred from the signature's own
+// header, since a repo only signs commits one way.
+func verifyCommitSignature(commit *object.Commit, allowedSigners string) (string, error) {
+	if commit.PGPSignature == "" {
+		return status.StatusFailure, fmt.Errorf("commit %s is not signed", commit.Hash)
+	}
+	if allowedSigners == "" {
+		return status.StatusFailure, fmt.Errorf("require_signed_commits is set but allowed_signers is not configured")
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(commit.PGPSignature), sshSignatureHeader) {
+		return verifySSHCommitSignature(commit, allowedSigners)
+	}
+
+	// #nosec G304
+	keyring, err := os.ReadFile(allowedSigners)
+	if err != nil {
+		return status.StatusFailure, fmt.Errorf("failed to read allowed_signers keyring: %w", err)
+	}
+
+	if _, err := commit.Verify(string(keyring)); err != nil {
+		return status.StatusFailure, fmt.Errorf("signature verification failed for commit %s: %w", commit.Hash, err)
+	}
+	return status.StatusSuccess, nil
+}
+
+// verifySSHCommitSignature verifies commit's SSH signature via
+// `ssh-keygen -Y verify`, the same tool and allowed_signers format git
+// itself uses for gpg.format=ssh. allowedSigners is a path to an
+// allowed_signers file mapping principals (here, committer email addresses)
+// to their authorized public keys.
+func verifySSHCommitSignature(commit *object.Commit, allowedSigners string) (string, error) {
+	sshKeygenBin, err := exec.LookPath("ssh-keygen")
+	if err != nil {
+		return status.StatusFailure, fmt.Errorf("SSH-signed commit %s requires ssh-keygen to verify: %w", commit.Hash, err)
+	}
+
+	sigFile, err := os.CreateTemp("", "commit-*.sig")
+	if err != nil {
+		return status.StatusFailure, fmt.Errorf("failed to create temp file for signature: %w", err)
+	}
+	defer os.Remove(sigFile.Name())
+	if _, err := sigFile.WriteString(commit.PGPSignature); err != nil {
+		sigFile.Close()
+		return status.StatusFailure, fmt.Errorf("failed to write signature to temp file: %w", err)
+	}
+	if err := sigFile.Close(); err != nil {
+		return status.StatusFailure, fmt.Errorf("failed to write signature to temp file: %w", err)
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		return status.StatusFailure, fmt.Errorf("failed to encode commit %s for verification: %w", commit.Hash, err)
+	}
+	payloadReader, err := encoded.Reader()
+	if err != nil {
+		return status.StatusFailure, fmt.Errorf("failed to read encoded commit %s: %w", commit.Hash, err)
+	}
+	var payload bytes.Buffer
+	if _, err := payload.ReadFrom(payloadReader); err != nil {
+		return status.StatusFailure, fmt.Errorf("failed to read encoded commit %s: %w", commit.Hash, err)
+	}
+
+	// #nosec G204
+	cmd := exec.Command(sshKeygenBin, "-Y", "verify",
+		"-f", allowedSigners,
+		"-I", commit.Committer.Email,
+		"-n", "git",
+		"-s", sigFile.Name(),
+	)
+	cmd.Stdin = &payload
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return status.StatusFailure, fmt.Errorf("signature verification failed for commit %s: %w: %s", commit.Hash, err, bytes.TrimSpace(output))
+	}
+	return status.StatusSuccess, nil
+}
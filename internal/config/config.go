@@ -4,9 +4,14 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -21,27 +26,116 @@ type GUIConfig struct {
 // GlobalConfig holds the global configuration stored in ~/.buenosaires/config.toml.
 // This configuration applies as the default for all repositories.
 type GlobalConfig struct {
-	User          string    `toml:"user"`
-	LogDir        string    `toml:"log_dir"`
-	Branch        string    `toml:"branch"`
-	SyncInterval  int       `toml:"sync_interval"`
-	GUI           GUIConfig `toml:"gui"`
-	RepositoryURL string    `toml:"repository_url"`
+	User              string         `toml:"user"`
+	LogDir            string         `toml:"log_dir"`
+	Branch            string         `toml:"branch"`
+	SyncInterval      int            `toml:"sync_interval"`
+	GUI               GUIConfig      `toml:"gui"`
+	RepositoryURL     string         `toml:"repository_url"`
+	Registries        []RegistryAuth `toml:"registries"`
+	Repos             []RepoWatch    `toml:"repos"`
+	MaxConcurrentRuns int            `toml:"max_concurrent_runs"` // Bounds how many scripts/builds run at once across all monitored repos (0 for the default)
+}
+
+// RepoWatch configures a single repository for the monitor loop to watch
+// concurrently, under a `[[repos]]` array. Fields left empty fall back to
+// the matching GlobalConfig value.
+type RepoWatch struct {
+	Path         string `toml:"path"`          // Path to the repository's working directory
+	Branch       string `toml:"branch"`        // Branch to monitor (overrides the global default)
+	LogDir       string `toml:"log_dir"`       // Log directory (overrides the global default)
+	SyncInterval int    `toml:"sync_interval"` // Seconds between syncs (overrides the global default)
+}
+
+// RegistryAuth describes how to authenticate against a single container
+// registry for image pushes, configured under `[[registries]]`. It mirrors
+// `docker login`'s -u/-p/-e flags, except the password is never stored in
+// plaintext: it is resolved at push time from either PasswordEnv (an
+// environment variable) or CredentialHelper (an external
+// "docker-credential-*"-style helper).
+type RegistryAuth struct {
+	URL              string `toml:"url"`               // Registry URL, e.g. "registry.example.com"
+	Username         string `toml:"username"`          // Registry username
+	PasswordEnv      string `toml:"password_env"`      // Environment variable holding the password/token
+	CredentialHelper string `toml:"credential_helper"` // External helper queried for the password, docker-credential-helper protocol
+	Email            string `toml:"email"`             // Registry account email, if required
+}
+
+// credentialHelperOutput is the JSON a docker-credential-helper-style
+// program writes to stdout in response to a "get" request on stdin.
+type credentialHelperOutput struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// ResolvePassword returns the registry password, read from PasswordEnv or
+// obtained by invoking CredentialHelper - never from the config file
+// itself, which only ever names where to find it.
+func (r RegistryAuth) ResolvePassword() (string, error) {
+	if r.PasswordEnv != "" {
+		value, ok := os.LookupEnv(r.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("registry %s: environment variable %q is not set", r.URL, r.PasswordEnv)
+		}
+		return value, nil
+	}
+
+	if r.CredentialHelper != "" {
+		cmd := exec.Command(r.CredentialHelper, "get")
+		cmd.Stdin = strings.NewReader(r.URL)
+		output, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("registry %s: credential helper %q failed: %w", r.URL, r.CredentialHelper, err)
+		}
+		var parsed credentialHelperOutput
+		if err := json.Unmarshal(output, &parsed); err != nil {
+			return "", fmt.Errorf("registry %s: credential helper %q returned invalid output: %w", r.URL, r.CredentialHelper, err)
+		}
+		return parsed.Secret, nil
+	}
+
+	return "", fmt.Errorf("registry %s: no password_env or credential_helper configured", r.URL)
 }
 
 // PluginConfig holds configuration specific to a plugin.
 type PluginConfig struct {
-	Enabled      bool   `toml:"enabled"`
-	FolderToScan string `toml:"folder_to_scan"`
+	Enabled      bool     `toml:"enabled"`
+	FolderToScan string   `toml:"folder_to_scan"`
+	Version      string   `toml:"version"`    // Plugin version/ref to pin, e.g. "shell@v2"
+	Alias        string   `toml:"alias"`      // Alias the plugin is registered under, if any
+	Privileges   []string `toml:"privileges"` // Privileges this repo grants the plugin (sudo, network, host-path-access, ...)
+	Push         bool     `toml:"push"`       // docker: push a successful build to the configured registry
+	Registry     string   `toml:"registry"`   // docker: registry URL to push to, matched against the global [[registries]]
+	Repository   string   `toml:"repository"` // docker: repository/image path within the registry, e.g. "myorg/myimage"
+}
+
+// SandboxConfig controls running committed scripts inside an ephemeral
+// container instead of directly on the host.
+type SandboxConfig struct {
+	Type           string   `toml:"type"`            // Container runtime to use, e.g. "docker"; empty disables sandboxing
+	Image          string   `toml:"image"`           // Image to run the script in, e.g. "alpine:latest"
+	Mounts         []string `toml:"mounts"`          // Additional bind mounts, "host:container[:ro]"
+	Env            []string `toml:"env"`             // Environment variables passed to the container, "NAME=VALUE"
+	Network        string   `toml:"network"`         // Docker network mode, e.g. "none" or "bridge"
+	MemoryLimit    string   `toml:"memory_limit"`    // Memory limit, e.g. "256m"
+	CPULimit       string   `toml:"cpu_limit"`       // CPU limit, e.g. "0.5"
+	TimeoutSeconds int      `toml:"timeout_seconds"` // Kill the container after this many seconds (0 for no timeout)
 }
 
 // RepoConfig holds configuration specific to a repository.
 // This is stored in the repository's config.toml file and overrides global settings.
 type RepoConfig struct {
-	User      string                  `toml:"user"`       // User to run scripts as (overrides global)
-	LogDir    string                  `toml:"log_dir"`    // Log directory (overrides global)
-	AllowSudo bool                    `toml:"allow_sudo"` // Whether scripts can use sudo
-	Plugins   map[string]PluginConfig `toml:"plugins"`    // Per-plugin configuration
+	User                 string                  `toml:"user"`                   // User to run scripts as (overrides global)
+	LogDir               string                  `toml:"log_dir"`                // Log directory (overrides global)
+	AllowSudo            bool                    `toml:"allow_sudo"`             // Whether scripts can use sudo
+	Sandbox              SandboxConfig           `toml:"sandbox"`                // Run scripts in an ephemeral container instead of on the host
+	RequireSignedCommits bool                    `toml:"require_signed_commits"` // Refuse to run assets from unsigned or untrusted commits
+	AllowedSigners       string                  `toml:"allowed_signers"`        // Path to an armored PGP keyring (for GPG-signed commits) or an SSH allowed_signers file (for SSH-signed commits)
+	WebhookSecret        string                  `toml:"webhook_secret"`         // Shared secret used to validate incoming push webhooks
+	MaxRunTimeSeconds    int                     `toml:"max_run_time_seconds"`   // Kills a running script past this many seconds (0 for no limit)
+	MinLintSeverity      string                  `toml:"min_lint_severity"`      // Refuse to run scripts with a lint diagnostic at or above this severity: error|warning|info|style (empty disables the gate)
+	Plugins              map[string]PluginConfig `toml:"plugins"`                // Per-plugin configuration
 }
 
 // GetConfigDir returns the path to the .buenosaires configuration directory in the user's home.
@@ -110,6 +204,107 @@ func SaveGlobalConfig(config GlobalConfig) error {
 	return toml.NewEncoder(file).Encode(config)
 }
 
+// DecodeGlobalConfig parses a GlobalConfig from TOML read from r, e.g. for
+// loading a candidate configuration from standard input.
+func DecodeGlobalConfig(r io.Reader) (GlobalConfig, error) {
+	var config GlobalConfig
+	if _, err := toml.NewDecoder(r).Decode(&config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// Validate reports whether a GlobalConfig has the values buenosaires needs
+// to actually run: a user, a log directory, a branch to monitor, a
+// repository to scan, and - if the web GUI is enabled - a GUI port.
+func Validate(cfg GlobalConfig) error {
+	var missing []string
+	if cfg.User == "" {
+		missing = append(missing, "user")
+	}
+	if cfg.LogDir == "" {
+		missing = append(missing, "log_dir")
+	}
+	if cfg.Branch == "" {
+		missing = append(missing, "branch")
+	}
+	if cfg.RepositoryURL == "" {
+		missing = append(missing, "repository_url")
+	}
+	if cfg.GUI.Enabled && cfg.GUI.Port <= 0 {
+		missing = append(missing, "gui.port")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required configuration value(s): %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// SetField updates a single field of a GlobalConfig identified by its
+// dotted TOML path (e.g. "user" or "gui.port"), parsing value into the
+// field's type. It powers `buenosaires config set <path> <value>`.
+func SetField(cfg *GlobalConfig, path, value string) error {
+	segments := strings.Split(path, ".")
+	v := reflect.ValueOf(cfg).Elem()
+
+	for i, segment := range segments {
+		field, err := fieldByTOMLTag(v, segment)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if i == len(segments)-1 {
+			return setScalar(field, value)
+		}
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("%s: %q is not a table", path, segment)
+		}
+		v = field
+	}
+	return nil
+}
+
+// fieldByTOMLTag finds the struct field of v whose `toml:"..."` tag matches
+// name.
+func fieldByTOMLTag(v reflect.Value, name string) (reflect.Value, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]
+		if tag == name {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown field %q", name)
+}
+
+// setScalar assigns a string, int, bool, or []string value parsed from a
+// command-line argument into field.
+func setScalar(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected a boolean, got %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice field type %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(strings.Split(value, ",")))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Kind())
+	}
+	return nil
+}
+
 // LoadRepoConfig loads the repository-specific configuration from config.toml in the repo directory.
 // Returns an error if the file cannot be read or parsed.
 func LoadRepoConfig(repoPath string) (RepoConfig, error) {
@@ -124,4 +319,4 @@ func LoadRepoConfig(repoPath string) (RepoConfig, error) {
 		return config, err
 	}
 	return config, nil
-}
\ No newline at end of file
+}
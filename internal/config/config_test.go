@@ -50,4 +50,118 @@ func TestSaveAndLoadGlobalConfig(t *testing.T) {
 	if !reflect.DeepEqual(expectedConfig, loadedConfig) {
 		t.Errorf("Loaded config does not match expected config.\nExpected: %+v\nGot:      %+v", expectedConfig, loadedConfig)
 	}
-}
\ No newline at end of file
+}
+
+func TestValidate(t *testing.T) {
+	complete := GlobalConfig{
+		User:          "alice",
+		LogDir:        "/tmp/logs",
+		Branch:        "main",
+		RepositoryURL: "https://example.com/repo.git",
+	}
+	if err := Validate(complete); err != nil {
+		t.Errorf("Expected a complete config to be valid, got: %v", err)
+	}
+
+	incomplete := GlobalConfig{User: "alice"}
+	if err := Validate(incomplete); err == nil {
+		t.Error("Expected validation to fail for a config missing required fields")
+	}
+
+	guiWithoutPort := complete
+	guiWithoutPort.GUI = GUIConfig{Enabled: true}
+	if err := Validate(guiWithoutPort); err == nil {
+		t.Error("Expected validation to fail when the GUI is enabled without a port")
+	}
+}
+
+func TestSetField(t *testing.T) {
+	cfg := GlobalConfig{}
+
+	if err := SetField(&cfg, "user", "bob"); err != nil {
+		t.Fatalf("Failed to set user: %v", err)
+	}
+	if cfg.User != "bob" {
+		t.Errorf("Expected user to be set to bob, got %q", cfg.User)
+	}
+
+	if err := SetField(&cfg, "gui.port", "9100"); err != nil {
+		t.Fatalf("Failed to set gui.port: %v", err)
+	}
+	if cfg.GUI.Port != 9100 {
+		t.Errorf("Expected gui.port to be set to 9100, got %d", cfg.GUI.Port)
+	}
+
+	if err := SetField(&cfg, "gui.enabled", "true"); err != nil {
+		t.Fatalf("Failed to set gui.enabled: %v", err)
+	}
+	if !cfg.GUI.Enabled {
+		t.Error("Expected gui.enabled to be set to true")
+	}
+
+	if err := SetField(&cfg, "gui.port", "not-a-number"); err == nil {
+		t.Error("Expected setting gui.port to a non-integer value to fail")
+	}
+
+	if err := SetField(&cfg, "nonexistent", "value"); err == nil {
+		t.Error("Expected setting an unknown field to fail")
+	}
+}
+
+func TestRegistryAuthResolvePassword(t *testing.T) {
+	t.Setenv("BUENOSAIRES_TEST_REGISTRY_TOKEN", "s3cr3t")
+
+	auth := RegistryAuth{URL: "registry.example.com", PasswordEnv: "BUENOSAIRES_TEST_REGISTRY_TOKEN"}
+	password, err := auth.ResolvePassword()
+	if err != nil {
+		t.Fatalf("Failed to resolve password from env: %v", err)
+	}
+	if password != "s3cr3t" {
+		t.Errorf("Expected password %q, got %q", "s3cr3t", password)
+	}
+
+	missingEnv := RegistryAuth{URL: "registry.example.com", PasswordEnv: "BUENOSAIRES_TEST_REGISTRY_TOKEN_UNSET"}
+	if _, err := missingEnv.ResolvePassword(); err == nil {
+		t.Error("Expected resolving an unset env var to fail")
+	}
+
+	unconfigured := RegistryAuth{URL: "registry.example.com"}
+	if _, err := unconfigured.ResolvePassword(); err == nil {
+		t.Error("Expected resolving with no password_env or credential_helper to fail")
+	}
+}
+
+func TestSaveAndLoadGlobalConfigWithRepos(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test-config-repos")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", originalHome)
+
+	expectedConfig := GlobalConfig{
+		User:              "testuser",
+		Branch:            "main",
+		MaxConcurrentRuns: 2,
+		Repos: []RepoWatch{
+			{Path: "/repos/one", Branch: "main"},
+			{Path: "/repos/two", Branch: "staging", LogDir: "/var/log/two", SyncInterval: 30},
+		},
+	}
+
+	if err := SaveGlobalConfig(expectedConfig); err != nil {
+		t.Fatalf("Failed to save global config: %v", err)
+	}
+
+	loadedConfig, err := LoadGlobalConfig()
+	if err != nil {
+		t.Fatalf("Failed to load global config: %v", err)
+	}
+
+	if !reflect.DeepEqual(expectedConfig, loadedConfig) {
+		t.Errorf("Loaded config does not match expected config.\nExpected: %+v\nGot:      %+v", expectedConfig, loadedConfig)
+	}
+}
@@ -0,0 +1,263 @@
+// Package manager implements an explicit install/enable/disable lifecycle
+// for Buenos Aires plugins, modeled after the Docker plugin Backend
+// interface. Unlike the old model where every compiled-in plugin always
+// ran, plugins must be installed and then enabled before the runtime will
+// dispatch to them. Privileges reports the full, static list of privileges
+// (sudo, network, host path access, ...) a plugin requests; Enable does not
+// compare it against anything already granted and asks for no
+// acknowledgment - `buenosaires plugin enable` only prints the list before
+// enabling.
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"buenosaires/internal/config"
+)
+
+// PluginEntry is a single plugin's record in the registry.
+type PluginEntry struct {
+	Name        string            `json:"name"`
+	Alias       string            `json:"alias,omitempty"`
+	Ref         string            `json:"ref"`
+	Enabled     bool              `json:"enabled"`
+	Privileges  []string          `json:"privileges"`
+	Config      map[string]string `json:"config,omitempty"`
+	InstalledAt time.Time         `json:"installed_at"`
+}
+
+// Registry is the on-disk record of every plugin the manager knows about,
+// persisted as ~/.buenosaires/plugins/registry.json.
+type Registry struct {
+	Plugins map[string]PluginEntry `json:"plugins"`
+}
+
+// InstallOptions customizes how a plugin is registered.
+type InstallOptions struct {
+	// Alias lets a repo refer to the plugin under a different name than
+	// the one derived from ref (e.g. pinning "shell@v2" as "shell").
+	Alias string
+}
+
+// builtinPrivileges maps the plugins compiled into this binary to the
+// privileges they may request. Plugins installed from an external ref that
+// isn't one of these names are granted no privileges until a real plugin
+// manifest format exists.
+var builtinPrivileges = map[string][]string{
+	"docker": {"network", "host-path-access"},
+	"shell":  {"sudo", "network", "host-path-access"},
+}
+
+// Manager owns the on-disk plugin registry under ~/.buenosaires/plugins.
+type Manager struct {
+	registryPath string
+}
+
+// NewManager creates a Manager rooted at the current user's config
+// directory, creating the plugins directory if it doesn't exist yet.
+func NewManager() (*Manager, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	pluginsDir := filepath.Join(configDir, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0750); err != nil {
+		return nil, err
+	}
+	return &Manager{registryPath: filepath.Join(pluginsDir, "registry.json")}, nil
+}
+
+// load reads the registry from disk, returning an empty one if it doesn't
+// exist yet.
+func (m *Manager) load() (Registry, error) {
+	registry := Registry{Plugins: make(map[string]PluginEntry)}
+	// #nosec G304
+	data, err := os.ReadFile(m.registryPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return registry, err
+	}
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return registry, err
+	}
+	if registry.Plugins == nil {
+		registry.Plugins = make(map[string]PluginEntry)
+	}
+	return registry, nil
+}
+
+// save persists the registry to disk.
+func (m *Manager) save(registry Registry) error {
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.registryPath, data, 0600)
+}
+
+// Privileges returns the privileges a plugin ref requests. For the plugins
+// compiled into this binary this is a static table; unknown refs request no
+// privileges.
+func (m *Manager) Privileges(ref string) ([]string, error) {
+	privs, ok := builtinPrivileges[ref]
+	if !ok {
+		return nil, nil
+	}
+	out := make([]string, len(privs))
+	copy(out, privs)
+	sort.Strings(out)
+	return out, nil
+}
+
+// Install registers a plugin in the registry without enabling it. The
+// plugin stays disabled, and therefore undispatched, until Enable is called.
+func (m *Manager) Install(ref string, opts InstallOptions) (PluginEntry, error) {
+	if ref == "" {
+		return PluginEntry{}, fmt.Errorf("plugin ref must not be empty")
+	}
+	name := ref
+	if opts.Alias != "" {
+		name = opts.Alias
+	}
+
+	registry, err := m.load()
+	if err != nil {
+		return PluginEntry{}, err
+	}
+
+	privileges, err := m.Privileges(ref)
+	if err != nil {
+		return PluginEntry{}, err
+	}
+
+	entry := PluginEntry{
+		Name:        name,
+		Alias:       opts.Alias,
+		Ref:         ref,
+		Enabled:     false,
+		Privileges:  privileges,
+		InstalledAt: time.Now(),
+	}
+	registry.Plugins[name] = entry
+
+	if err := m.save(registry); err != nil {
+		return PluginEntry{}, err
+	}
+	return entry, nil
+}
+
+// Enable turns on dispatch for an installed plugin. Callers that need to
+// confirm the privileges a plugin requests before enabling it should call
+// Privileges(entry.Ref) first and present the diff to the user.
+func (m *Manager) Enable(name string) error {
+	registry, err := m.load()
+	if err != nil {
+		return err
+	}
+	entry, ok := registry.Plugins[name]
+	if !ok {
+		return fmt.Errorf("plugin %q is not installed", name)
+	}
+	entry.Enabled = true
+	registry.Plugins[name] = entry
+	return m.save(registry)
+}
+
+// Disable turns off dispatch for a plugin without removing its registry
+// entry or granted configuration.
+func (m *Manager) Disable(name string) error {
+	registry, err := m.load()
+	if err != nil {
+		return err
+	}
+	entry, ok := registry.Plugins[name]
+	if !ok {
+		return fmt.Errorf("plugin %q is not installed", name)
+	}
+	entry.Enabled = false
+	registry.Plugins[name] = entry
+	return m.save(registry)
+}
+
+// Inspect returns the registry entry for a single plugin.
+func (m *Manager) Inspect(name string) (PluginEntry, error) {
+	registry, err := m.load()
+	if err != nil {
+		return PluginEntry{}, err
+	}
+	entry, ok := registry.Plugins[name]
+	if !ok {
+		return PluginEntry{}, fmt.Errorf("plugin %q is not installed", name)
+	}
+	return entry, nil
+}
+
+// List returns every installed plugin, sorted by name.
+func (m *Manager) List() ([]PluginEntry, error) {
+	registry, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]PluginEntry, 0, len(registry.Plugins))
+	for _, entry := range registry.Plugins {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Remove deletes a plugin's registry entry. An enabled plugin is refused
+// unless force is set, mirroring `docker plugin rm`.
+func (m *Manager) Remove(name string, force bool) error {
+	registry, err := m.load()
+	if err != nil {
+		return err
+	}
+	entry, ok := registry.Plugins[name]
+	if !ok {
+		return fmt.Errorf("plugin %q is not installed", name)
+	}
+	if entry.Enabled && !force {
+		return fmt.Errorf("plugin %q is enabled, disable it first or pass force", name)
+	}
+	delete(registry.Plugins, name)
+	return m.save(registry)
+}
+
+// Set merges configuration overrides into a plugin's registry entry.
+func (m *Manager) Set(name string, args map[string]string) error {
+	registry, err := m.load()
+	if err != nil {
+		return err
+	}
+	entry, ok := registry.Plugins[name]
+	if !ok {
+		return fmt.Errorf("plugin %q is not installed", name)
+	}
+	if entry.Config == nil {
+		entry.Config = make(map[string]string)
+	}
+	for k, v := range args {
+		entry.Config[k] = v
+	}
+	registry.Plugins[name] = entry
+	return m.save(registry)
+}
+
+// IsEnabled reports whether the named plugin is both installed and enabled.
+// The runtime uses this to decide whether a commit touching that plugin's
+// files should be dispatched.
+func (m *Manager) IsEnabled(name string) bool {
+	entry, err := m.Inspect(name)
+	if err != nil {
+		return false
+	}
+	return entry.Enabled
+}
@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"os"
+	"testing"
+)
+
+// withTempHome points HOME at a temp dir for the duration of the test so
+// NewManager doesn't touch the real user's ~/.buenosaires.
+func withTempHome(t *testing.T) {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "manager-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+		os.RemoveAll(tmpDir)
+	})
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	withTempHome(t)
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	entry, err := m.Install("shell", InstallOptions{})
+	if err != nil {
+		t.Fatalf("Failed to install plugin: %v", err)
+	}
+	if entry.Enabled {
+		t.Error("Expected newly installed plugin to be disabled")
+	}
+	if len(entry.Privileges) == 0 {
+		t.Error("Expected shell plugin to request privileges")
+	}
+
+	if m.IsEnabled("shell") {
+		t.Error("Expected plugin to not be enabled before Enable is called")
+	}
+
+	if err := m.Enable("shell"); err != nil {
+		t.Fatalf("Failed to enable plugin: %v", err)
+	}
+	if !m.IsEnabled("shell") {
+		t.Error("Expected plugin to be enabled after Enable")
+	}
+
+	if err := m.Set("shell", map[string]string{"image": "alpine:latest"}); err != nil {
+		t.Fatalf("Failed to set plugin config: %v", err)
+	}
+	inspected, err := m.Inspect("shell")
+	if err != nil {
+		t.Fatalf("Failed to inspect plugin: %v", err)
+	}
+	if inspected.Config["image"] != "alpine:latest" {
+		t.Errorf("Expected config override to persist, got: %+v", inspected.Config)
+	}
+
+	entries, err := m.List()
+	if err != nil {
+		t.Fatalf("Failed to list plugins: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 plugin, got %d", len(entries))
+	}
+
+	if err := m.Disable("shell"); err != nil {
+		t.Fatalf("Failed to disable plugin: %v", err)
+	}
+	if m.IsEnabled("shell") {
+		t.Error("Expected plugin to be disabled")
+	}
+
+	if err := m.Remove("shell", false); err != nil {
+		t.Fatalf("Failed to remove disabled plugin: %v", err)
+	}
+	if _, err := m.Inspect("shell"); err == nil {
+		t.Error("Expected inspect to fail after removal")
+	}
+}
+
+func TestManagerRemoveRefusesEnabledWithoutForce(t *testing.T) {
+	withTempHome(t)
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if _, err := m.Install("docker", InstallOptions{}); err != nil {
+		t.Fatalf("Failed to install plugin: %v", err)
+	}
+	if err := m.Enable("docker"); err != nil {
+		t.Fatalf("Failed to enable plugin: %v", err)
+	}
+
+	if err := m.Remove("docker", false); err == nil {
+		t.Error("Expected Remove to refuse an enabled plugin without force")
+	}
+	if err := m.Remove("docker", true); err != nil {
+		t.Errorf("Expected forced Remove to succeed, got: %v", err)
+	}
+}
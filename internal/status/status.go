@@ -19,20 +19,23 @@ const (
 	StatusSkipped = "skipped" // Script processing was skipped
 )
 
-// ScriptStatus represents the execution status of a single shell script.
-// It tracks the outcome of lint, test, and run phases, along with a timestamp.
-type ScriptStatus struct {
-	LintStatus    string    `json:"lint_status"`    // Result of linting (pending/success/failure)
-	TestStatus    string    `json:"test_status"`    // Result of testing (pending/success/failure/skipped)
-	RunStatus     string    `json:"run_status"`     // Result of execution (pending/success/failure)
-	Timestamp     time.Time `json:"timestamp"`      // When the status was last updated
-	OverallStatus string    `json:"overall_status"` // Overall result of all phases
+// AssetStatus represents the execution status of a single asset processed
+// by a plugin - a shell script, a Dockerfile build, or anything else a
+// Plugin claims from the run loop. It tracks the outcome of lint, test,
+// and run phases, along with a timestamp.
+type AssetStatus struct {
+	LintStatus      string    `json:"lint_status"`      // Result of linting/validation (pending/success/failure/skipped)
+	TestStatus      string    `json:"test_status"`      // Result of testing (pending/success/failure/skipped)
+	RunStatus       string    `json:"run_status"`       // Result of execution (pending/success/failure, or a shell.RunStatus value such as timeout/cancelled/lint-gated/signal:<n>)
+	SignatureStatus string    `json:"signature_status"` // Result of commit signature verification (success/failure/skipped)
+	Timestamp       time.Time `json:"timestamp"`        // When the status was last updated
+	OverallStatus   string    `json:"overall_status"`   // Overall result of all phases
 }
 
-// Status represents the overall status tracking for all scripts in the repository.
-// It maps script names to their execution status.
+// Status represents the overall status tracking for all assets in the repository.
+// It maps asset names (script paths, Dockerfile paths, ...) to their execution status.
 type Status struct {
-	Scripts map[string]ScriptStatus `json:"scripts"`
+	Scripts map[string]AssetStatus `json:"scripts"`
 }
 
 // getStatusFilePath returns the path to the status.json file within the repository.
@@ -55,7 +58,7 @@ func LoadStatus(repoPath string) (*Status, error) {
 	}
 	// If the status file doesn't exist yet, return an empty status
 	if _, err := os.Stat(statusFilePath); os.IsNotExist(err) {
-		return &Status{Scripts: make(map[string]ScriptStatus)}, nil
+		return &Status{Scripts: make(map[string]AssetStatus)}, nil
 	}
 
 	// #nosec G304
@@ -94,14 +97,15 @@ func (s *Status) SaveStatus(repoPath string) error {
 	return os.WriteFile(statusFilePath, data, 0600)
 }
 
-// UpdateScriptStatus updates the status of a specific script.
-// It creates a new ScriptStatus entry with the provided status values and current timestamp.
-func (s *Status) UpdateScriptStatus(scriptName, lintStatus, testStatus, runStatus, overallStatus string) {
-	s.Scripts[scriptName] = ScriptStatus{
-		LintStatus:    lintStatus,
-		TestStatus:    testStatus,
-		RunStatus:     runStatus,
-		Timestamp:     time.Now(),
-		OverallStatus: overallStatus,
+// UpdateScriptStatus updates the status of a specific asset.
+// It creates a new AssetStatus entry with the provided status values and current timestamp.
+func (s *Status) UpdateScriptStatus(scriptName, lintStatus, testStatus, runStatus, overallStatus, signatureStatus string) {
+	s.Scripts[scriptName] = AssetStatus{
+		LintStatus:      lintStatus,
+		TestStatus:      testStatus,
+		RunStatus:       runStatus,
+		SignatureStatus: signatureStatus,
+		Timestamp:       time.Now(),
+		OverallStatus:   overallStatus,
 	}
-}
\ No newline at end of file
+}
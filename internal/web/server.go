@@ -1,22 +1,46 @@
 // Package web provides a simple HTTP server for viewing script execution logs.
-// It serves two main endpoints:
-//   - / : Lists all available log files
+// It serves several endpoints:
+//   - / : Lists all available log files, plus the asset generation history for each script
 //   - /logs/{filename} : Displays the contents of a specific log file
+//   - /logs/{filename}/stream : Tails a log file and streams new bytes as Server-Sent Events
+//   - /api/logs : JSON list of log files with their latest asset manifest
+//   - /api/logs/{filename} : JSON asset manifest for a single log file
+//   - /plugins/shell/assets/{name}.json : The latest asset manifest for a script, resolved through the content-addressable AssetStore
+//   - /plugins/shell/assets/blobs/{digest} : A single blob (lint output, exec output, script source, ...) by its sha256 digest
+//   - POST /hooks/{provider} : Receives a Gitea/Gitea-Coop/GitHub/GitLab push webhook and signals the monitor loop to sync immediately
 package web
 
 import (
+	"encoding/json"
+	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"buenosaires/plugins/shell"
 )
 
-// logDir stores the directory path where log files are located.
+// logDirs stores the directory paths where log files are located, and
+// repoPaths the corresponding monitored repo path for each - one entry per
+// monitored repo, in the same order, so the list/API endpoints aggregate
+// across all of them while still being able to open the right repo-scoped
+// AssetStore for a given script. syncNow and webhookSecret back the
+// /hooks/{provider} webhook receiver: a verified push notification is
+// signaled on syncNow so the monitor loop can react immediately instead of
+// waiting for its next poll.
 var (
-	logDir string
+	logDirs       []string
+	repoPaths     []string
+	syncNow       chan<- struct{}
+	webhookSecret string
 )
 
 // HTML templates for rendering the web interface.
@@ -34,21 +58,97 @@ const (
             <div class="pf-v5-c-content">
                 <h1>Log Files</h1>
             </div>
+            <div class="pf-v5-c-toolbar">
+                <input id="filter-status" placeholder="Filter by status">
+                <input id="filter-plugin" placeholder="Filter by script name">
+                <input id="filter-commit" placeholder="Filter by commit">
+            </div>
         </section>
         <section class="pf-v5-c-page__main-section">
-            <div class="pf-v5-l-gallery pf-m-gutter">
-                {{range .}}
-                <div class="pf-v5-l-gallery__item">
+            <div id="log-gallery" class="pf-v5-l-gallery pf-m-gutter">
+                {{range .LogFiles}}
+                <div class="pf-v5-l-gallery__item" data-logfile="{{.}}">
                     <div class="pf-v5-c-card">
                         <div class="pf-v5-c-card__body">
                             <a href="/logs/{{.}}">{{.}}</a>
+                            <span class="status-badge"></span>
+                            <span class="lint-badge"></span>
                         </div>
                     </div>
                 </div>
                 {{end}}
             </div>
         </section>
+        <section class="pf-v5-c-page__main-section">
+            <div class="pf-v5-c-content">
+                <h2>Script History</h2>
+            </div>
+            {{range $history := .Histories}}
+            <table class="pf-v5-c-table">
+                <caption>{{$history.ScriptName}}</caption>
+                <thead>
+                    <tr><th>Generation</th><th>Commit</th><th>Status</th></tr>
+                </thead>
+                <tbody>
+                    {{range $history.Generations}}
+                    <tr>
+                        <td><a href="/plugins/shell/assets/{{$history.ScriptName}}.json?generation={{.Generation}}">{{.Generation}}</a></td>
+                        <td>{{.CommitHash}}</td>
+                        <td>{{.Status}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+            {{end}}
+        </section>
     </div>
+    <script>
+        document.addEventListener('DOMContentLoaded', function() {
+            let entries = [];
+
+            function applyFilters() {
+                const status = document.getElementById('filter-status').value.trim().toLowerCase();
+                const pluginFilter = document.getElementById('filter-plugin').value.trim().toLowerCase();
+                const commit = document.getElementById('filter-commit').value.trim().toLowerCase();
+
+                document.querySelectorAll('#log-gallery [data-logfile]').forEach(function(card) {
+                    const name = card.getAttribute('data-logfile');
+                    const entry = entries.find(e => e.name === name) || {};
+                    const manifest = entry.manifest || {};
+                    // Manifests record no plugin name; the second filter matches the script name.
+                    const matches =
+                        (!status || (manifest.status || '').toLowerCase().includes(status)) &&
+                        (!pluginFilter || (manifest.script_name || '').toLowerCase().includes(pluginFilter)) &&
+                        (!commit || (manifest.commit_hash || '').toLowerCase().includes(commit));
+                    card.style.display = matches ? '' : 'none';
+
+                    const badge = card.querySelector('.status-badge');
+                    if (badge && manifest.status) {
+                        badge.textContent = manifest.status;
+                    }
+
+                    const lintBadge = card.querySelector('.lint-badge');
+                    const counts = (manifest.lint_summary || {}).counts || {};
+                    const unresolved = (counts.error || 0) + (counts.warning || 0);
+                    if (lintBadge && unresolved > 0) {
+                        lintBadge.textContent = unresolved + ' lint issue' + (unresolved === 1 ? '' : 's');
+                    }
+                });
+            }
+
+            fetch('/api/logs')
+                .then(response => response.json())
+                .then(data => {
+                    entries = data;
+                    applyFilters();
+                })
+                .catch(error => console.error('Error fetching /api/logs:', error));
+
+            ['filter-status', 'filter-plugin', 'filter-commit'].forEach(function(id) {
+                document.getElementById(id).addEventListener('input', applyFilters);
+            });
+        });
+    </script>
 </body>
 </html>`
 
@@ -65,11 +165,15 @@ const (
             <div class="pf-v5-c-content">
                 <h1>Log: {{.Title}}</h1>
             </div>
+            <div class="pf-v5-c-toolbar">
+                <button id="pause-toggle" class="pf-v5-c-button pf-m-secondary">Pause</button>
+                <button id="jump-latest" class="pf-v5-c-button pf-m-secondary">Jump to latest</button>
+            </div>
         </section>
         <section class="pf-v5-c-page__main-section">
             <div class="pf-v5-c-card">
                 <div class="pf-v5-c-card__body">
-                    <pre>{{.Content}}</pre>
+                    <pre id="log-content">{{.Content}}</pre>
                 </div>
             </div>
             <div class="pf-v5-c-accordion">
@@ -87,15 +191,48 @@ const (
     <script>
         document.addEventListener('DOMContentLoaded', function() {
             const logName = '{{.Title}}';
-            // Fetch the asset JSON
-            fetch('/plugins/shell/assets/' + logName + '.json')
+            const scriptName = logName.replace(/\.log$/, '');
+            // Fetch the latest manifest, resolved through the content-addressable asset store.
+            fetch('/plugins/shell/assets/' + scriptName + '.json')
                 .then(response => response.json())
                 .then(data => {
                     document.getElementById('asset-json').textContent = JSON.stringify(data, null, 2);
                 })
                 .catch(error => {
-                    console.error('Error fetching asset JSON:', error);
+                    console.error('Error fetching asset manifest:', error);
                 });
+
+            const content = document.getElementById('log-content');
+            let paused = false;
+            let autoScroll = true;
+
+            document.getElementById('pause-toggle').addEventListener('click', function() {
+                paused = !paused;
+                this.textContent = paused ? 'Resume' : 'Pause';
+            });
+            document.getElementById('jump-latest').addEventListener('click', function() {
+                autoScroll = true;
+                content.scrollTop = content.scrollHeight;
+            });
+            content.addEventListener('scroll', function() {
+                autoScroll = content.scrollTop + content.clientHeight >= content.scrollHeight - 4;
+            });
+
+            if (!!window.EventSource) {
+                const source = new EventSource('/logs/' + encodeURIComponent(logName) + '/stream');
+                source.onmessage = function(event) {
+                    if (paused) {
+                        return;
+                    }
+                    content.textContent += event.data + '\n';
+                    if (autoScroll) {
+                        content.scrollTop = content.scrollHeight;
+                    }
+                };
+                source.onerror = function() {
+                    source.close();
+                };
+            }
         });
     </script>
 </body>
@@ -103,43 +240,158 @@ const (
 )
 
 // StartServer starts the HTTP server on the specified address.
-// It serves the log listing page and individual log file viewers.
+// It serves the log listing page and individual log file viewers, aggregated
+// across every directory in lDirs (one per monitored repo).
 // Parameters:
 //   - addr: The address to listen on (e.g., ":8080")
-//   - lDir: The directory containing log files
-func StartServer(addr, lDir string) {
-	logDir = lDir
+//   - lDirs: The directories containing log files, one per monitored repo
+//   - rPaths: The monitored repo path backing each entry of lDirs, same order, used to open that repo's AssetStore
+//   - syncNowCh: Signaled when a verified webhook arrives, to wake the monitor loop; may be nil to disable the webhook receiver
+//   - secret: Shared secret webhook payloads are validated against
+func StartServer(addr string, lDirs []string, rPaths []string, syncNowCh chan<- struct{}, secret string) {
+	logDirs = lDirs
+	repoPaths = rPaths
+	syncNow = syncNowCh
+	webhookSecret = secret
 	// Register HTTP handlers
 	http.Handle("/assets/", http.FileServer(http.Dir(".")))
 	http.HandleFunc("/", handleList)
-	http.HandleFunc("/logs/", handleView)
+	http.HandleFunc("/api/logs", handleAPILogs)
+	http.HandleFunc("/api/logs/", handleAPILog)
+	http.HandleFunc("/plugins/shell/assets/blobs/", handleAssetBlob)
+	http.HandleFunc("/plugins/shell/assets/", handleAssetManifest)
+	http.HandleFunc("/logs/", handleLogsPrefix)
+	http.HandleFunc("/hooks/", handleWebhook)
 
 	log.Printf("Starting web server on %s", addr)
 	server := &http.Server{
-		Addr:         addr,
+		Addr: addr,
+		// The /logs/{name}/stream endpoint holds its connection open for as
+		// long as the client is watching, so the server sets no write
+		// timeout at all, for any endpoint; ReadTimeout still bounds reading
+		// each request.
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
+		WriteTimeout: 0,
 	}
 	if err := server.ListenAndServe(); err != nil {
 		log.Fatalf("Failed to start web server: %v", err)
 	}
 }
 
-// handleList handles requests to the root path and displays a list of all log files.
+// scriptHistory is the generation history of a single script, rendered on
+// the list page alongside its log file.
+type scriptHistory struct {
+	ScriptName  string
+	Generations []shell.Manifest
+}
+
+// listPageData is the data handed to listTemplate.
+type listPageData struct {
+	LogFiles  []string
+	Histories []scriptHistory
+}
+
+// logEntry is one row of the /api/logs response: a log file paired with its
+// most recent asset manifest, if any.
+type logEntry struct {
+	Name     string          `json:"name"`
+	Manifest *shell.Manifest `json:"manifest,omitempty"`
+}
+
+// listLogFiles returns the names of every .log file across all of logDirs,
+// deduplicated by name so two repos logging the same script name don't
+// produce duplicate entries.
+func listLogFiles() ([]string, error) {
+	seen := make(map[string]bool)
+	var logFiles []string
+	for _, dir := range logDirs {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			log.Printf("Failed to read log directory %s: %v", dir, err)
+			continue
+		}
+		for _, file := range files {
+			if !file.IsDir() && strings.HasSuffix(file.Name(), ".log") && !seen[file.Name()] {
+				seen[file.Name()] = true
+				logFiles = append(logFiles, file.Name())
+			}
+		}
+	}
+	return logFiles, nil
+}
+
+// resolveManifest tries each monitored repo's AssetStore in turn, returning
+// the first one that has a manifest for scriptName at generation (0 for
+// latest). Mirrors resolveLogPath's loop-and-first-match approach, since a
+// script name alone doesn't say which repo it came from.
+func resolveManifest(scriptName string, generation int) (shell.Manifest, error) {
+	var lastErr error = errLogNotFound
+	for _, repoPath := range repoPaths {
+		store, err := shell.NewAssetStore(repoPath)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		manifest, err := store.Resolve(scriptName, generation)
+		if err == nil {
+			return manifest, nil
+		}
+		lastErr = err
+	}
+	return shell.Manifest{}, lastErr
+}
+
+// historyForScript returns the full generation history for scriptName from
+// whichever monitored repo's AssetStore has one.
+func historyForScript(scriptName string) ([]shell.Manifest, error) {
+	var lastErr error
+	for _, repoPath := range repoPaths {
+		store, err := shell.NewAssetStore(repoPath)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		history, err := store.History(scriptName)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(history) > 0 {
+			return history, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// handleLogsPrefix dispatches requests under /logs/ to the streaming
+// endpoint or the synchronous view handler depending on the trailing path
+// segment.
+func handleLogsPrefix(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/stream") {
+		handleLogStream(w, r)
+		return
+	}
+	handleView(w, r)
+}
+
+// handleList handles requests to the root path and displays a list of all
+// log files along with each script's generation history from the asset store.
 func handleList(w http.ResponseWriter, r *http.Request) {
-	files, err := os.ReadDir(logDir)
+	logFiles, err := listLogFiles()
 	if err != nil {
 		http.Error(w, "Failed to read log directory", http.StatusInternalServerError)
 		return
 	}
 
-	// Filter for .log files only
-	var logFiles []string
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".log") {
-			logFiles = append(logFiles, file.Name())
+	var histories []scriptHistory
+	for _, logFile := range logFiles {
+		scriptName := strings.TrimSuffix(logFile, ".log")
+		generations, err := historyForScript(scriptName)
+		if err != nil || len(generations) == 0 {
+			continue
 		}
+		histories = append(histories, scriptHistory{ScriptName: scriptName, Generations: generations})
 	}
 
 	tmpl, err := template.New("list").Parse(listTemplate)
@@ -148,34 +400,96 @@ func handleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := tmpl.Execute(w, logFiles); err != nil {
+	data := listPageData{LogFiles: logFiles, Histories: histories}
+	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, "Failed to execute template", http.StatusInternalServerError)
 	}
 }
 
-// handleView handles requests to view a specific log file.
-// It extracts the filename from the URL path and displays its contents.
-func handleView(w http.ResponseWriter, r *http.Request) {
-	logName := strings.TrimPrefix(r.URL.Path, "/logs/")
-	
-	// Additional validation to prevent directory traversal
-	if strings.Contains(logName, "..") || strings.Contains(logName, "/") || strings.Contains(logName, "\\") {
+// handleAPILogs returns the full log file list as JSON, each paired with its
+// latest asset manifest (if one exists) so the UI can render status badges
+// and filter by status/script name/commit without parsing the HTML page.
+func handleAPILogs(w http.ResponseWriter, r *http.Request) {
+	logFiles, err := listLogFiles()
+	if err != nil {
+		http.Error(w, "Failed to read log directory", http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]logEntry, 0, len(logFiles))
+	for _, logFile := range logFiles {
+		entry := logEntry{Name: logFile}
+		scriptName := strings.TrimSuffix(logFile, ".log")
+		if manifest, err := resolveManifest(scriptName, 0); err == nil {
+			entry.Manifest = &manifest
+		}
+		entries = append(entries, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, "Failed to encode log list", http.StatusInternalServerError)
+	}
+}
+
+// handleAPILog returns the latest asset manifest for a single log file as
+// JSON, keyed by the log file name (e.g. /api/logs/deploy.sh.log).
+func handleAPILog(w http.ResponseWriter, r *http.Request) {
+	logName := strings.TrimPrefix(r.URL.Path, "/api/logs/")
+	if logName == "" || strings.Contains(logName, "..") || strings.Contains(logName, "/") {
 		http.Error(w, "Invalid log file name", http.StatusBadRequest)
 		return
 	}
-	
-	logPath := filepath.Clean(filepath.Join(logDir, logName))
 
-	// Sanitize the file path to prevent directory traversal attacks
-	if !strings.HasPrefix(logPath, filepath.Clean(logDir)) {
-		http.Error(w, "Invalid log file path", http.StatusBadRequest)
+	scriptName := strings.TrimSuffix(logName, ".log")
+	manifest, err := resolveManifest(scriptName, 0)
+	if err != nil {
+		http.NotFound(w, r)
 		return
 	}
 
-	// Check if the log file exists
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		http.Error(w, "Failed to encode manifest", http.StatusInternalServerError)
+	}
+}
+
+// errLogNotFound distinguishes a well-formed name that doesn't exist in any
+// logDirs entry from a malformed one, so callers can return 404 vs 400.
+var errLogNotFound = fmt.Errorf("log file not found")
+
+// resolveLogPath validates a log file name from a URL path segment and
+// returns its sanitized absolute path within whichever of logDirs actually
+// contains it.
+func resolveLogPath(logName string) (string, error) {
+	if logName == "" || strings.Contains(logName, "..") || strings.Contains(logName, "/") || strings.Contains(logName, "\\") {
+		return "", fmt.Errorf("invalid log file name")
+	}
+
+	for _, dir := range logDirs {
+		logPath := filepath.Clean(filepath.Join(dir, logName))
+		if !strings.HasPrefix(logPath, filepath.Clean(dir)) {
+			continue
+		}
+		if _, err := os.Stat(logPath); err == nil {
+			return logPath, nil
+		}
+	}
+	return "", errLogNotFound
+}
+
+// handleView handles requests to view a specific log file.
+// It extracts the filename from the URL path and displays its contents.
+func handleView(w http.ResponseWriter, r *http.Request) {
+	logName := strings.TrimPrefix(r.URL.Path, "/logs/")
+
+	logPath, err := resolveLogPath(logName)
+	if err == errLogNotFound {
 		http.NotFound(w, r)
 		return
+	} else if err != nil {
+		http.Error(w, "Invalid log file name", http.StatusBadRequest)
+		return
 	}
 
 	// Read the log file contents
@@ -202,4 +516,160 @@ func handleView(w http.ResponseWriter, r *http.Request) {
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, "Failed to execute template", http.StatusInternalServerError)
 	}
-}
\ No newline at end of file
+}
+
+// handleLogStream tails a log file and pushes newly appended bytes to the
+// client as Server-Sent Events, using fsnotify to wake up on writes instead
+// of polling. It streams line-by-line; the synchronous /logs/{name} view
+// remains available as a fallback for clients that can't use SSE.
+func handleLogStream(w http.ResponseWriter, r *http.Request) {
+	logName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/logs/"), "/stream")
+
+	logPath, err := resolveLogPath(logName)
+	if err == errLogNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, "Invalid log file name", http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(logPath) // #nosec G304
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		http.Error(w, "Failed to watch log file", http.StatusInternalServerError)
+		return
+	}
+	defer watcher.Close()
+	if err := watcher.Add(logPath); err != nil {
+		http.Error(w, "Failed to watch log file", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Seek to the end so the stream only carries new content; the initial
+	// snapshot was already rendered by the synchronous view.
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		http.Error(w, "Failed to seek log file", http.StatusInternalServerError)
+		return
+	}
+
+	writeLines := func() bool {
+		buf := make([]byte, 0)
+		chunk := make([]byte, 4096)
+		for {
+			n, err := file.Read(chunk)
+			if n > 0 {
+				buf = append(buf, chunk[:n]...)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if len(buf) == 0 {
+			return false
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(buf), "\n"), "\n") {
+			fmt.Fprintf(w, "data: %s\n\n", line)
+		}
+		flusher.Flush()
+		return true
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				writeLines()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleAssetManifest resolves the manifest for a script through the
+// content-addressable AssetStore. The script is named by the URL path
+// (with a trailing ".json"), and an optional ?generation= query parameter
+// selects a specific generation; without it, the most recent one is served.
+func handleAssetManifest(w http.ResponseWriter, r *http.Request) {
+	scriptName := strings.TrimPrefix(r.URL.Path, "/plugins/shell/assets/")
+	scriptName = strings.TrimSuffix(scriptName, ".json")
+	if scriptName == "" || strings.Contains(scriptName, "..") {
+		http.Error(w, "Invalid script name", http.StatusBadRequest)
+		return
+	}
+
+	generation := 0
+	if g := r.URL.Query().Get("generation"); g != "" {
+		parsed, err := strconv.Atoi(g)
+		if err != nil {
+			http.Error(w, "Invalid generation", http.StatusBadRequest)
+			return
+		}
+		generation = parsed
+	}
+
+	manifest, err := resolveManifest(scriptName, generation)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		http.Error(w, "Failed to encode manifest", http.StatusInternalServerError)
+	}
+}
+
+// handleAssetBlob serves a single blob (lint output, exec output, script
+// source, ...) by its sha256 digest. Blobs are content-addressed and shared
+// across every repo's AssetStore, so which repo we open it through doesn't
+// matter.
+func handleAssetBlob(w http.ResponseWriter, r *http.Request) {
+	digest := strings.TrimPrefix(r.URL.Path, "/plugins/shell/assets/blobs/")
+	if digest == "" || strings.ContainsAny(digest, "./\\") {
+		http.Error(w, "Invalid blob digest", http.StatusBadRequest)
+		return
+	}
+
+	store, err := shell.NewAssetStore("")
+	if err != nil {
+		http.Error(w, "Failed to open asset store", http.StatusInternalServerError)
+		return
+	}
+
+	content, err := store.Get(digest)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(content)
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -8,8 +9,25 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"buenosaires/plugins/shell"
 )
 
+func withTempHome(t *testing.T) {
+	t.Helper()
+	tmpDir, err := ioutil.TempDir("", "web-test-home")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+		os.RemoveAll(tmpDir)
+	})
+}
+
 func TestHandlers(t *testing.T) {
 	// Create a temporary directory for logs
 	tmpDir, err := ioutil.TempDir("", "test-logs")
@@ -29,7 +47,7 @@ func TestHandlers(t *testing.T) {
 	}
 
 	// Set the log directory for the handlers
-	logDir = tmpDir
+	logDirs = []string{tmpDir}
 
 	// Create a new server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -54,7 +72,7 @@ func TestHandlers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedList := "<li><a href=\"/logs/script1.log\">script1.log</a></li>"
+	expectedList := "<a href=\"/logs/script1.log\">script1.log</a>"
 	if !strings.Contains(string(body), expectedList) {
 		t.Errorf("handler returned unexpected body: got %v want %v",
 			string(body), expectedList)
@@ -73,9 +91,135 @@ func TestHandlers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedView := "<pre>log content 1</pre>"
+	expectedView := "<pre id=\"log-content\">log content 1</pre>"
 	if !strings.Contains(string(body), expectedView) {
 		t.Errorf("handler returned unexpected body: got %v want %v",
 			string(body), expectedView)
 	}
-}
\ No newline at end of file
+}
+
+func TestAPILogsAndAPILog(t *testing.T) {
+	withTempHome(t)
+
+	tmpDir, err := ioutil.TempDir("", "test-logs-api")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "deploy.sh.log")
+	if err := ioutil.WriteFile(logFile, []byte("deployed"), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+	logDirs = []string{tmpDir}
+	repoPaths = []string{""}
+
+	store, err := shell.NewAssetStore("")
+	if err != nil {
+		t.Fatalf("Failed to create asset store: %v", err)
+	}
+	if _, err := store.Put(shell.Run{
+		ScriptName: "deploy.sh",
+		CommitHash: "abc123",
+		Status:     "success",
+		LintPassed: true,
+	}); err != nil {
+		t.Fatalf("Failed to put run: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/logs" {
+			handleAPILogs(w, r)
+		} else {
+			handleAPILog(w, r)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var entries []logEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		t.Fatalf("Failed to decode /api/logs response: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "deploy.sh.log" {
+		t.Fatalf("Expected a single deploy.sh.log entry, got %+v", entries)
+	}
+	if entries[0].Manifest == nil || entries[0].Manifest.Status != "success" {
+		t.Errorf("Expected the entry's manifest to carry status=success, got %+v", entries[0].Manifest)
+	}
+
+	resp, err = http.Get(server.URL + "/api/logs/deploy.sh.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var manifest shell.Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		t.Fatalf("Failed to decode /api/logs/deploy.sh.log response: %v", err)
+	}
+	if manifest.CommitHash != "abc123" {
+		t.Errorf("Expected manifest commit hash abc123, got %q", manifest.CommitHash)
+	}
+}
+
+func TestHandleLogStreamPushesAppendedLines(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-logs-stream")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logFile := filepath.Join(tmpDir, "build.log")
+	if err := ioutil.WriteFile(logFile, []byte("initial\n"), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+	logDirs = []string{tmpDir}
+
+	server := httptest.NewServer(http.HandlerFunc(handleLogStream))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/logs/build.log/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %q", ct)
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 1024)
+		n, _ := resp.Body.Read(buf)
+		done <- string(buf[:n])
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open log file for append: %v", err)
+	}
+	if _, err := f.WriteString("new line\n"); err != nil {
+		t.Fatalf("Failed to append to log file: %v", err)
+	}
+	f.Close()
+
+	select {
+	case chunk := <-done:
+		if !strings.Contains(chunk, "new line") {
+			t.Errorf("Expected streamed chunk to contain the appended line, got %q", chunk)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for streamed log data")
+	}
+}
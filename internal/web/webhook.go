@@ -0,0 +1,110 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// webhookVerifiers maps a provider name (the trailing segment of
+// /hooks/{provider}) to the function that authenticates its push payload.
+var webhookVerifiers = map[string]func(r *http.Request, body []byte, secret string) error{
+	"github":     verifyGitHubSignature,
+	"gitlab":     verifyGitLabToken,
+	"gitea":      verifyGiteaSignature,
+	"gitea-coop": verifyGiteaSignature,
+}
+
+// handleWebhook receives a push notification from a Git host, authenticates
+// it against webhookSecret, and - on success - signals syncNow so the
+// monitor loop reacts immediately instead of waiting for its next poll.
+func handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	provider := strings.TrimPrefix(r.URL.Path, "/hooks/")
+	verify, ok := webhookVerifiers[provider]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown webhook provider %q", provider), http.StatusNotFound)
+		return
+	}
+	if webhookSecret == "" {
+		http.Error(w, "Webhook receiver is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := verify(r, body, webhookSecret); err != nil {
+		log.Printf("Rejected %s webhook: %v", provider, err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if syncNow != nil {
+		select {
+		case syncNow <- struct{}{}:
+			log.Printf("Received %s webhook, triggering an immediate sync", provider)
+		default:
+			// A sync is already pending; nothing more to do.
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verifyGitHubSignature checks the HMAC-SHA256 signature GitHub sends in
+// X-Hub-Signature-256, formatted as "sha256=<hex>".
+func verifyGitHubSignature(r *http.Request, body []byte, secret string) error {
+	return verifyHMACSHA256(r.Header.Get("X-Hub-Signature-256"), "sha256=", body, secret)
+}
+
+// verifyGiteaSignature checks the HMAC-SHA256 signature Gitea (and its
+// Gitea-Coop fork) send in X-Gitea-Signature, a bare hex digest.
+func verifyGiteaSignature(r *http.Request, body []byte, secret string) error {
+	return verifyHMACSHA256(r.Header.Get("X-Gitea-Signature"), "", body, secret)
+}
+
+// verifyHMACSHA256 compares header (with prefix stripped) against the
+// HMAC-SHA256 hex digest of body keyed by secret.
+func verifyHMACSHA256(header, prefix string, body []byte, secret string) error {
+	if header == "" {
+		return fmt.Errorf("missing signature header")
+	}
+	signature := strings.TrimPrefix(header, prefix)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// verifyGitLabToken checks the plain shared-secret token GitLab sends in
+// X-Gitlab-Token - GitLab webhooks don't sign the payload, they just
+// present the configured secret directly.
+func verifyGitLabToken(r *http.Request, _ []byte, secret string) error {
+	token := r.Header.Get("X-Gitlab-Token")
+	if token == "" {
+		return fmt.Errorf("missing X-Gitlab-Token header")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return fmt.Errorf("token mismatch")
+	}
+	return nil
+}
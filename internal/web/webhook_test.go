@@ -0,0 +1,93 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleWebhookGitHubSignature(t *testing.T) {
+	webhookSecret = "test-secret"
+	defer func() { webhookSecret = "" }()
+
+	ch := make(chan struct{}, 1)
+	syncNow = ch
+	defer func() { syncNow = nil }()
+
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks/github", strings.NewReader(string(body)))
+	req.Header.Set("X-Hub-Signature-256", signature)
+	w := httptest.NewRecorder()
+
+	handleWebhook(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	select {
+	case <-ch:
+	default:
+		t.Error("Expected a sync signal to be sent on syncNow")
+	}
+}
+
+func TestHandleWebhookGitHubBadSignature(t *testing.T) {
+	webhookSecret = "test-secret"
+	defer func() { webhookSecret = "" }()
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks/github", strings.NewReader(`{}`))
+	req.Header.Set("X-Hub-Signature-256", "sha256=deadbeef")
+	w := httptest.NewRecorder()
+
+	handleWebhook(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a bad signature, got %d", w.Code)
+	}
+}
+
+func TestHandleWebhookGitLabToken(t *testing.T) {
+	webhookSecret = "gitlab-secret"
+	defer func() { webhookSecret = "" }()
+
+	ch := make(chan struct{}, 1)
+	syncNow = ch
+	defer func() { syncNow = nil }()
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks/gitlab", strings.NewReader(`{}`))
+	req.Header.Set("X-Gitlab-Token", "gitlab-secret")
+	w := httptest.NewRecorder()
+
+	handleWebhook(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	select {
+	case <-ch:
+	default:
+		t.Error("Expected a sync signal to be sent on syncNow")
+	}
+}
+
+func TestHandleWebhookUnknownProvider(t *testing.T) {
+	webhookSecret = "test-secret"
+	defer func() { webhookSecret = "" }()
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks/bitbucket", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+
+	handleWebhook(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown provider, got %d", w.Code)
+	}
+}
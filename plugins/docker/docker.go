@@ -2,17 +2,41 @@ package docker
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // DockerPlugin represents the Docker container plugin.
 type DockerPlugin struct{}
 
+// BuildOptions configures a BuildKit-era `docker build` invocation: which
+// stage to build, build-time variables, platform/labels, layer cache
+// sources/destinations, and the secrets or SSH agents a RUN instruction may
+// mount.
+type BuildOptions struct {
+	Target    string            // --target, selects a single stage of a multi-stage build
+	BuildArgs map[string]string // --build-arg NAME=VALUE
+	Platform  string            // --platform
+	Labels    map[string]string // --label NAME=VALUE
+	CacheFrom []string          // --cache-from
+	CacheTo   []string          // --cache-to
+	Secrets   []string          // --secret, e.g. "id=mysecret,src=./secret.txt"
+	SSH       []string          // --ssh, e.g. "default" or "key=./id_rsa"
+	NoCache   bool              // --no-cache
+	Pull      bool              // --pull
+}
+
 // LintAndValidate performs validation and linting on the Dockerfile/Containerfile.
+// It always runs our own parser pass - which flags empty `RUN []`, dangling
+// COPY line-continuations, unpinned base images, and unknown instructions -
+// and additionally shells out to hadolint when it's available for deeper
+// best-practice checks.
 func (p *DockerPlugin) LintAndValidate(containerFilePath string) (string, error) {
 	var finalOutput bytes.Buffer
 
@@ -23,14 +47,33 @@ func (p *DockerPlugin) LintAndValidate(containerFilePath string) (string, error)
 
 	finalOutput.WriteString(fmt.Sprintf("Validating container file: %s\n", filepath.Base(containerFilePath)))
 
-	// 2. Basic syntax validation with docker build --dry-run (if available in Docker 20.10+)
-	// Note: Not all Docker versions support --dry-run, so we'll skip this for now
-	// and rely on hadolint for validation
+	// #nosec G304
+	content, err := os.ReadFile(containerFilePath)
+	if err != nil {
+		return finalOutput.String(), fmt.Errorf("failed to read container file: %w", err)
+	}
+
+	parsed, err := ParseDockerfile(string(content))
+	if err != nil {
+		return finalOutput.String(), fmt.Errorf("failed to parse container file: %w", err)
+	}
+
+	var fatal []LintIssue
+	for _, issue := range parsed.Issues {
+		finalOutput.WriteString(fmt.Sprintf("%s:%d: %s: %s\n", filepath.Base(containerFilePath), issue.Line, issue.Severity, issue.Message))
+		if issue.Severity == SeverityError {
+			fatal = append(fatal, issue)
+		}
+	}
+	if len(fatal) > 0 {
+		return finalOutput.String(), fmt.Errorf("dockerfile parser found %d error(s), first: %s", len(fatal), fatal[0].Message)
+	}
 
-	// 3. Lint with hadolint (Dockerfile linter)
+	// 2. Lint with hadolint (Dockerfile linter), best-effort - our own
+	// parser pass above already covers the checks this plugin depends on.
 	cmdHadolint := exec.Command("hadolint", containerFilePath)
 	hadolintOutput, err := cmdHadolint.CombinedOutput()
-	
+
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			// hadolint returns exit code 1 for warnings/errors
@@ -61,77 +104,237 @@ func (p *DockerPlugin) LintAndValidate(containerFilePath string) (string, error)
 	return finalOutput.String(), nil
 }
 
+// buildArgs assembles the `docker build` argument list for the given
+// Dockerfile/Containerfile, image tag, and BuildOptions.
+func buildArgs(containerFilePath, containerDir, fullImageName string, opts BuildOptions) []string {
+	args := []string{"build", "-f", containerFilePath, "-t", fullImageName}
+
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	for name, value := range opts.BuildArgs {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", name, value))
+	}
+	if opts.Platform != "" {
+		args = append(args, "--platform", opts.Platform)
+	}
+	for name, value := range opts.Labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", name, value))
+	}
+	for _, from := range opts.CacheFrom {
+		args = append(args, "--cache-from", from)
+	}
+	for _, to := range opts.CacheTo {
+		args = append(args, "--cache-to", to)
+	}
+	for _, secret := range opts.Secrets {
+		args = append(args, "--secret", secret)
+	}
+	for _, ssh := range opts.SSH {
+		args = append(args, "--ssh", ssh)
+	}
+	if opts.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if opts.Pull {
+		args = append(args, "--pull")
+	}
+
+	args = append(args, containerDir)
+	return args
+}
+
 // Build builds the Docker image from the Dockerfile/Containerfile.
-func (p *DockerPlugin) Build(containerFilePath, imageName, imageTag string) (string, error) {
+func (p *DockerPlugin) Build(containerFilePath, imageName, imageTag string, opts BuildOptions) (string, error) {
 	// Get the directory containing the Dockerfile/Containerfile
 	containerDir := filepath.Dir(containerFilePath)
-	
+
 	// Construct the image name with tag
 	fullImageName := fmt.Sprintf("%s:%s", imageName, imageTag)
-	
+
 	// Build the Docker image
-	cmdBuild := exec.Command("docker", "build", 
-		"-f", containerFilePath,
-		"-t", fullImageName,
-		containerDir,
-	)
-	
+	cmdBuild := exec.Command("docker", buildArgs(containerFilePath, containerDir, fullImageName, opts)...)
+
 	output, err := cmdBuild.CombinedOutput()
 	if err != nil {
 		return string(output), fmt.Errorf("docker build failed: %w", err)
 	}
-	
+
 	return string(output), nil
 }
 
 // Run builds and optionally runs the Docker container.
 // For safety, by default we only build the image. Running containers requires explicit configuration.
-func (p *DockerPlugin) Run(containerFilePath, imageName, imageTag string, autoRun bool) (string, error) {
+func (p *DockerPlugin) Run(containerFilePath, imageName, imageTag string, autoRun bool, opts BuildOptions) (string, error) {
 	var finalOutput bytes.Buffer
-	
+
 	// First, build the image
-	buildOutput, err := p.Build(containerFilePath, imageName, imageTag)
+	buildOutput, err := p.Build(containerFilePath, imageName, imageTag, opts)
 	finalOutput.WriteString("=== BUILD OUTPUT ===\n")
 	finalOutput.WriteString(buildOutput)
 	finalOutput.WriteString("\n")
-	
+
 	if err != nil {
 		return finalOutput.String(), err
 	}
-	
+
 	finalOutput.WriteString(fmt.Sprintf("Successfully built image: %s:%s\n", imageName, imageTag))
-	
+
 	// Optionally run the container (disabled by default for safety)
 	if autoRun {
 		fullImageName := fmt.Sprintf("%s:%s", imageName, imageTag)
 		containerName := fmt.Sprintf("%s-%s", imageName, imageTag)
-		
+
 		// Remove old container if it exists
 		exec.Command("docker", "rm", "-f", containerName).Run()
-		
+
 		// Run the container
-		cmdRun := exec.Command("docker", "run", 
+		cmdRun := exec.Command("docker", "run",
 			"--name", containerName,
 			"-d", // Run in detached mode
 			fullImageName,
 		)
-		
+
 		runOutput, err := cmdRun.CombinedOutput()
 		finalOutput.WriteString("=== RUN OUTPUT ===\n")
 		finalOutput.WriteString(string(runOutput))
-		
+
 		if err != nil {
 			return finalOutput.String(), fmt.Errorf("docker run failed: %w", err)
 		}
-		
+
 		finalOutput.WriteString(fmt.Sprintf("Successfully started container: %s\n", containerName))
 	} else {
 		finalOutput.WriteString("Container not started (auto_run disabled). Image is ready to use.\n")
 	}
-	
+
 	return finalOutput.String(), nil
 }
 
+// RegistryAuth carries the credentials used to authenticate a `docker push`,
+// mirroring `docker login`'s -u/-p/-e flags. Password is expected to already
+// be resolved by the caller (from an env var or credential helper) - Push
+// never reads secrets from config itself.
+type RegistryAuth struct {
+	URL      string // Registry URL, e.g. "registry.example.com"; empty pushes to Docker Hub
+	Username string
+	Password string
+	Email    string
+}
+
+// Push tags imageName:imageTag for auth's registry (if set) and pushes it,
+// logging in first when credentials are provided.
+func (p *DockerPlugin) Push(imageName, imageTag string, auth RegistryAuth) (string, error) {
+	var finalOutput bytes.Buffer
+
+	fullImageName := fmt.Sprintf("%s:%s", imageName, imageTag)
+	targetImage := fullImageName
+	if auth.URL != "" {
+		targetImage = fmt.Sprintf("%s/%s", auth.URL, fullImageName)
+
+		cmdTag := exec.Command("docker", "tag", fullImageName, targetImage)
+		tagOutput, err := cmdTag.CombinedOutput()
+		finalOutput.Write(tagOutput)
+		if err != nil {
+			return finalOutput.String(), fmt.Errorf("docker tag failed: %w", err)
+		}
+	}
+
+	if auth.Username != "" {
+		loginArgs := []string{"login", "-u", auth.Username, "--password-stdin"}
+		if auth.URL != "" {
+			loginArgs = append(loginArgs, auth.URL)
+		}
+		cmdLogin := exec.Command("docker", loginArgs...)
+		cmdLogin.Stdin = strings.NewReader(auth.Password)
+		loginOutput, err := cmdLogin.CombinedOutput()
+		finalOutput.Write(loginOutput)
+		if err != nil {
+			return finalOutput.String(), fmt.Errorf("docker login failed: %w", err)
+		}
+	}
+
+	cmdPush := exec.Command("docker", "push", targetImage)
+	pushOutput, err := cmdPush.CombinedOutput()
+	finalOutput.Write(pushOutput)
+	if err != nil {
+		return finalOutput.String(), fmt.Errorf("docker push failed: %w", err)
+	}
+
+	finalOutput.WriteString(fmt.Sprintf("Successfully pushed image: %s\n", targetImage))
+	return finalOutput.String(), nil
+}
+
+// SandboxOptions configures an ephemeral `docker run` used to execute a
+// single untrusted script instead of running it on the host.
+type SandboxOptions struct {
+	Image       string        // Image to run the script in, e.g. "alpine:latest"
+	Mounts      []string      // Additional bind mounts, "host:container[:ro]"
+	Env         []string      // Environment variables, "NAME=VALUE"
+	Network     string        // --network, e.g. "none" or "bridge"
+	MemoryLimit string        // --memory, e.g. "256m"
+	CPULimit    string        // --cpus, e.g. "0.5"
+	Timeout     time.Duration // Kill the container if it runs longer than this (0 for no timeout)
+}
+
+// RunSandboxed runs scriptPath inside an ephemeral, read-only-mounted
+// container built from opts.Image and returns its combined output together
+// with its exit code. The container is removed as soon as it exits
+// (`docker run --rm`), so nothing about the script persists on the host.
+// A non-zero returned exit code with a nil error means the script itself
+// failed inside the container; a non-nil error means the container never
+// produced an exit code at all (docker couldn't be started, ctx was
+// canceled, or the timeout killed it first).
+func RunSandboxed(ctx context.Context, scriptPath string, opts SandboxOptions) (string, int, error) {
+	if opts.Image == "" {
+		return "", -1, fmt.Errorf("sandbox: no image configured")
+	}
+
+	var cancel context.CancelFunc
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"run", "--rm", "-v", fmt.Sprintf("%s:/script.sh:ro", scriptPath)}
+	for _, mount := range opts.Mounts {
+		args = append(args, "-v", mount)
+	}
+	for _, env := range opts.Env {
+		args = append(args, "--env", env)
+	}
+	if opts.Network != "" {
+		args = append(args, "--network", opts.Network)
+	}
+	if opts.MemoryLimit != "" {
+		args = append(args, "--memory", opts.MemoryLimit)
+	}
+	if opts.CPULimit != "" {
+		args = append(args, "--cpus", opts.CPULimit)
+	}
+	args = append(args, opts.Image, "/bin/sh", "/script.sh")
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	output, err := cmd.CombinedOutput()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), -1, fmt.Errorf("sandbox: script timed out after %s", opts.Timeout)
+	}
+	if ctx.Err() != nil {
+		return string(output), -1, fmt.Errorf("sandbox: %w", ctx.Err())
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return string(output), -1, fmt.Errorf("docker run failed: %w", err)
+	}
+
+	return string(output), 0, nil
+}
+
 // FindContainerFile looks for Dockerfile or Containerfile in the specified directory.
 // Returns the path to the file if found, or an error if not found.
 func FindContainerFile(dir string) (string, error) {
@@ -140,12 +343,12 @@ func FindContainerFile(dir string) (string, error) {
 	if _, err := os.Stat(dockerfilePath); err == nil {
 		return dockerfilePath, nil
 	}
-	
+
 	// Check for Containerfile
 	containerfilePath := filepath.Join(dir, "Containerfile")
 	if _, err := os.Stat(containerfilePath); err == nil {
 		return containerfilePath, nil
 	}
-	
+
 	return "", fmt.Errorf("no Dockerfile or Containerfile found in %s", dir)
 }
@@ -82,8 +82,8 @@ CMD ["echo", "Hello from Buenos Aires!"]
 	plugin := DockerPlugin{}
 	imageName := "buenosaires-test"
 	imageTag := "test"
-	
-	output, err := plugin.Build(dockerfilePath, imageName, imageTag)
+
+	output, err := plugin.Build(dockerfilePath, imageName, imageTag, BuildOptions{})
 	if err != nil {
 		t.Errorf("Expected successful build, but got error: %v\nOutput: %s", err, output)
 	}
@@ -122,9 +122,9 @@ CMD ["echo", "Hello from Buenos Aires!"]
 	plugin := DockerPlugin{}
 	imageName := "buenosaires-run-test"
 	imageTag := "test"
-	
+
 	// Test without auto-run (build only)
-	output, err := plugin.Run(dockerfilePath, imageName, imageTag, false)
+	output, err := plugin.Run(dockerfilePath, imageName, imageTag, false, BuildOptions{})
 	if err != nil {
 		t.Errorf("Expected successful run (build only), but got error: %v\nOutput: %s", err, output)
 	}
@@ -139,6 +139,50 @@ CMD ["echo", "Hello from Buenos Aires!"]
 	}()
 }
 
+func TestDockerPlugin_Push(t *testing.T) {
+	// Only run this test if Docker is available
+	if !isDockerAvailable() {
+		t.Skip("Docker is not available, skipping push test")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "docker-push-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	simpleDockerfile := `FROM alpine:latest
+CMD ["echo", "Hello from Buenos Aires!"]
+`
+	dockerfilePath := filepath.Join(tmpDir, "Dockerfile")
+	if err := os.WriteFile(dockerfilePath, []byte(simpleDockerfile), 0644); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	plugin := DockerPlugin{}
+	imageName := "buenosaires-push-test"
+	imageTag := "test"
+
+	if _, err := plugin.Build(dockerfilePath, imageName, imageTag, BuildOptions{}); err != nil {
+		t.Fatalf("Failed to build image for push test: %v", err)
+	}
+	defer func() {
+		exec := exec.Command("docker", "rmi", "-f", imageName+":"+imageTag)
+		exec.Run()
+	}()
+
+	// There's no registry listening on this port, so the push itself is
+	// expected to fail - we're only asserting that Build and tag
+	// succeeded and the push failure is surfaced as an error.
+	output, err := plugin.Push(imageName, imageTag, RegistryAuth{URL: "localhost:59999"})
+	if err == nil {
+		t.Error("Expected push to an unreachable registry to fail, but it succeeded")
+	}
+	if !strings.Contains(output, "localhost:59999") {
+		t.Errorf("Expected output to reference the target registry, but got: %s", output)
+	}
+}
+
 func TestFindContainerFile(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "find-container-test-")
 	if err != nil {
@@ -0,0 +1,347 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Stage is a single build stage declared by a FROM instruction.
+type Stage struct {
+	Index     int    // Position of this stage among all FROM instructions, starting at 0
+	Name      string // Name given by "AS <name>", empty if the stage isn't named
+	BaseImage string // Image reference after variable substitution
+}
+
+// LintIssue is a single problem found by ParseDockerfile, independent of
+// whatever hadolint may additionally report.
+type LintIssue struct {
+	Line     int    `json:"line"`
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+// Severity levels used by LintIssue.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ParseResult is everything our own Dockerfile parser pass extracts,
+// without requiring hadolint to be installed.
+type ParseResult struct {
+	SyntaxDirective string // e.g. "docker/dockerfile:1.4", from a leading "# syntax=" comment
+	Stages          []Stage
+	EntrypointForm  string // "json", "shell", or "" if never set
+	CmdForm         string // "json", "shell", or "" if never set
+	Issues          []LintIssue
+}
+
+// instruction is a single logical Dockerfile instruction after joining
+// backslash line-continuations.
+type instruction struct {
+	line int // 1-based line number the instruction started on
+	name string
+	args string
+	raw  string
+}
+
+// knownInstructions is the set of instructions this parser understands.
+// Anything else is flagged as unknown.
+var knownInstructions = map[string]bool{
+	"FROM": true, "RUN": true, "CMD": true, "LABEL": true, "MAINTAINER": true,
+	"EXPOSE": true, "ENV": true, "ADD": true, "COPY": true, "ENTRYPOINT": true,
+	"VOLUME": true, "USER": true, "WORKDIR": true, "ARG": true, "ONBUILD": true,
+	"STOPSIGNAL": true, "HEALTHCHECK": true, "SHELL": true,
+}
+
+var varRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// substituteVars expands ${NAME}, ${NAME:-default} and $NAME references
+// against the known ARG/ENV values collected so far.
+func substituteVars(s string, vars map[string]string) string {
+	return varRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := varRefPattern.FindStringSubmatch(match)
+		name := groups[1]
+		def := groups[3]
+		if name == "" {
+			name = groups[4]
+		}
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return def
+	})
+}
+
+// splitInstructions joins backslash-continued lines into single logical
+// instructions, skipping blank lines and plain comments.
+func splitInstructions(content string) []instruction {
+	var instructions []instruction
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineNo := 0
+	var pending strings.Builder
+	pendingStart := 0
+	danglingContinuation := false
+
+	flush := func() {
+		raw := strings.TrimSpace(pending.String())
+		pending.Reset()
+		if raw == "" {
+			return
+		}
+		parts := strings.SplitN(raw, " ", 2)
+		name := strings.ToUpper(parts[0])
+		args := ""
+		if len(parts) > 1 {
+			args = strings.TrimSpace(parts[1])
+		}
+		instructions = append(instructions, instruction{line: pendingStart, name: name, args: args, raw: raw})
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if pending.Len() == 0 {
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			pendingStart = lineNo
+		} else {
+			pending.WriteString(" ")
+		}
+
+		if strings.HasSuffix(trimmed, "\\") {
+			pending.WriteString(strings.TrimSuffix(trimmed, "\\"))
+			danglingContinuation = true
+			continue
+		}
+		danglingContinuation = false
+		pending.WriteString(trimmed)
+		flush()
+	}
+	if danglingContinuation {
+		// A backslash continuation with nothing following it - flush what we
+		// have so callers can still see (and flag) the broken instruction.
+		flush()
+	}
+	return instructions
+}
+
+// ParseDockerfile parses the content of a Dockerfile/Containerfile,
+// resolving ARG/ENV variable substitution and flagging the issues
+// LintAndValidate needs regardless of whether hadolint is installed.
+func ParseDockerfile(content string) (*ParseResult, error) {
+	result := &ParseResult{}
+	vars := make(map[string]string)
+
+	lines := strings.SplitN(content, "\n", 2)
+	if len(lines) > 0 {
+		firstLine := strings.TrimSpace(lines[0])
+		if strings.HasPrefix(firstLine, "#") && strings.Contains(firstLine, "syntax=") {
+			parts := strings.SplitN(firstLine, "syntax=", 2)
+			result.SyntaxDirective = strings.TrimSpace(parts[1])
+		}
+	}
+
+	instructions := splitInstructionsWithContinuationCheck(content, result)
+
+	stageIndex := -1
+	stageNames := make(map[string]bool)
+
+	for _, inst := range instructions {
+		switch inst.name {
+		case "ARG":
+			name, value, hasValue := strings.Cut(inst.args, "=")
+			name = strings.TrimSpace(name)
+			if hasValue {
+				vars[name] = substituteVars(strings.TrimSpace(value), vars)
+			}
+		case "ENV":
+			// ENV supports both "ENV NAME=value" and legacy "ENV NAME value".
+			if strings.Contains(inst.args, "=") {
+				name, value, _ := strings.Cut(inst.args, "=")
+				vars[strings.TrimSpace(name)] = substituteVars(strings.TrimSpace(value), vars)
+			} else {
+				parts := strings.SplitN(inst.args, " ", 2)
+				if len(parts) == 2 {
+					vars[strings.TrimSpace(parts[0])] = substituteVars(strings.TrimSpace(parts[1]), vars)
+				}
+			}
+		case "FROM":
+			stageIndex++
+			fields := strings.Fields(substituteVars(inst.args, vars))
+			if len(fields) == 0 {
+				continue
+			}
+			baseImage := fields[0]
+			stageName := ""
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "AS") {
+				stageName = fields[2]
+				stageNames[strings.ToLower(stageName)] = true
+			}
+			result.Stages = append(result.Stages, Stage{Index: stageIndex, Name: stageName, BaseImage: baseImage})
+
+			if !stageNames[strings.ToLower(baseImage)] && baseImage != "scratch" {
+				if !strings.ContainsAny(baseImage, "@") && !hasImageTag(baseImage) {
+					result.Issues = append(result.Issues, LintIssue{
+						Line: inst.line, Severity: SeverityWarning,
+						Message: fmt.Sprintf("base image %q is not pinned to a version", baseImage),
+					})
+				} else if strings.HasSuffix(baseImage, ":latest") {
+					result.Issues = append(result.Issues, LintIssue{
+						Line: inst.line, Severity: SeverityWarning,
+						Message: fmt.Sprintf("base image %q is pinned to the moving \"latest\" tag", baseImage),
+					})
+				}
+			}
+		case "COPY":
+			if strings.Contains(inst.args, "--from=") {
+				ref := extractFlag(inst.args, "--from")
+				if !referencesKnownStage(ref, stageNames, stageIndex) {
+					result.Issues = append(result.Issues, LintIssue{
+						Line: inst.line, Severity: SeverityError,
+						Message: fmt.Sprintf("COPY --from=%s references an unknown build stage", ref),
+					})
+				}
+			}
+		case "RUN":
+			if strings.TrimSpace(inst.args) == "[]" {
+				result.Issues = append(result.Issues, LintIssue{
+					Line: inst.line, Severity: SeverityError,
+					Message: "RUN [] has no command to execute",
+				})
+			}
+		case "ENTRYPOINT":
+			result.EntrypointForm = instructionForm(inst.args)
+		case "CMD":
+			result.CmdForm = instructionForm(inst.args)
+		default:
+			if !knownInstructions[inst.name] {
+				result.Issues = append(result.Issues, LintIssue{
+					Line: inst.line, Severity: SeverityError,
+					Message: fmt.Sprintf("unknown instruction %q", inst.name),
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// splitInstructionsWithContinuationCheck wraps splitInstructions and also
+// flags COPY instructions whose backslash continuation is never followed
+// by a continuation line.
+func splitInstructionsWithContinuationCheck(content string, result *ParseResult) []instruction {
+	rawLines := strings.Split(content, "\n")
+	instructions := splitInstructions(content)
+
+	for i, line := range rawLines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(strings.ToUpper(trimmed), "COPY") || !strings.HasSuffix(trimmed, "\\") {
+			continue
+		}
+		isLast := i == len(rawLines)-1
+		nextBlank := !isLast && strings.TrimSpace(rawLines[i+1]) == ""
+		if isLast || nextBlank {
+			result.Issues = append(result.Issues, LintIssue{
+				Line: i + 1, Severity: SeverityError,
+				Message: "dangling line continuation in COPY instruction",
+			})
+		}
+	}
+	return instructions
+}
+
+// instructionForm reports whether a CMD/ENTRYPOINT value uses the JSON
+// array ("exec") form or the plain shell form.
+func instructionForm(args string) string {
+	if strings.HasPrefix(strings.TrimSpace(args), "[") {
+		return "json"
+	}
+	return "shell"
+}
+
+// hasImageTag reports whether an image reference carries an explicit tag.
+func hasImageTag(ref string) bool {
+	// A reference may include a registry host with a port (host:port/repo),
+	// so only look for a colon after the final slash.
+	lastSlash := strings.LastIndex(ref, "/")
+	tagPart := ref
+	if lastSlash >= 0 {
+		tagPart = ref[lastSlash+1:]
+	}
+	return strings.Contains(tagPart, ":")
+}
+
+// extractFlag returns the value of a "--name=value" flag within a string
+// of space-separated arguments.
+func extractFlag(args, name string) string {
+	for _, field := range strings.Fields(args) {
+		if strings.HasPrefix(field, name+"=") {
+			return strings.TrimPrefix(field, name+"=")
+		}
+	}
+	return ""
+}
+
+// referencesKnownStage reports whether a COPY --from target refers to a
+// stage declared earlier in the file, either by name or by numeric index.
+func referencesKnownStage(ref string, stageNames map[string]bool, lastStageIndex int) bool {
+	if ref == "" {
+		return true // nothing to validate
+	}
+	if stageNames[strings.ToLower(ref)] {
+		return true
+	}
+	if idx, err := strconv.Atoi(ref); err == nil {
+		return idx >= 0 && idx < lastStageIndex
+	}
+	// An external image reference (not a prior stage) is always valid.
+	return strings.Contains(ref, "/") || strings.Contains(ref, ":") || strings.Contains(ref, ".")
+}
+
+// LoadDockerignore reads the .dockerignore patterns next to a
+// Dockerfile/Containerfile, returning an empty slice if none exists.
+func LoadDockerignore(contextDir string) ([]string, error) {
+	// #nosec G304
+	data, err := os.ReadFile(filepath.Join(contextDir, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// IsIgnored reports whether a relative path matches one of the given
+// .dockerignore patterns.
+func IsIgnored(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, path); err == nil && matched {
+			return true
+		}
+		if strings.HasPrefix(path, strings.TrimSuffix(pattern, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
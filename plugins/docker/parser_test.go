@@ -0,0 +1,187 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDockerfileMultiStageAndSyntaxDirective(t *testing.T) {
+	content := `# syntax=docker/dockerfile:1.4
+ARG VERSION=3.18
+FROM alpine:${VERSION} AS builder
+RUN --mount=type=cache,target=/root/.cache echo building
+FROM alpine:${VERSION}
+COPY --from=builder /app /app
+ENTRYPOINT ["/app"]
+CMD ["--help"]
+`
+	result, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("Failed to parse Dockerfile: %v", err)
+	}
+	if result.SyntaxDirective != "docker/dockerfile:1.4" {
+		t.Errorf("Expected syntax directive to be captured, got %q", result.SyntaxDirective)
+	}
+	if len(result.Stages) != 2 {
+		t.Fatalf("Expected 2 stages, got %d", len(result.Stages))
+	}
+	if result.Stages[0].Name != "builder" || result.Stages[0].BaseImage != "alpine:3.18" {
+		t.Errorf("Expected ARG substitution into FROM, got %+v", result.Stages[0])
+	}
+	if result.EntrypointForm != "json" || result.CmdForm != "json" {
+		t.Errorf("Expected JSON-form ENTRYPOINT/CMD to be detected, got entrypoint=%q cmd=%q", result.EntrypointForm, result.CmdForm)
+	}
+	for _, issue := range result.Issues {
+		if issue.Severity == SeverityError {
+			t.Errorf("Expected no errors for a valid multi-stage Dockerfile, got: %s", issue.Message)
+		}
+	}
+}
+
+func TestParseDockerfileArgDefaultSubstitution(t *testing.T) {
+	content := `FROM alpine:3.18
+ENV GREETING=hello
+RUN echo ${GREETING:-hi} ${MISSING:-fallback}
+CMD echo done
+`
+	result, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("Failed to parse Dockerfile: %v", err)
+	}
+	if result.CmdForm != "shell" {
+		t.Errorf("Expected shell-form CMD to be detected, got %q", result.CmdForm)
+	}
+}
+
+func TestParseDockerfileFlagsUnknownStageReference(t *testing.T) {
+	content := `FROM alpine:3.18 AS builder
+COPY --from=nonexistent /app /app
+`
+	result, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("Failed to parse Dockerfile: %v", err)
+	}
+	if !hasIssueContaining(result.Issues, "unknown build stage") {
+		t.Errorf("Expected an unknown build stage issue, got: %+v", result.Issues)
+	}
+}
+
+func TestParseDockerfileFlagsEmptyRun(t *testing.T) {
+	content := `FROM alpine:3.18
+RUN []
+`
+	result, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("Failed to parse Dockerfile: %v", err)
+	}
+	if !hasIssueContaining(result.Issues, "RUN [] has no command") {
+		t.Errorf("Expected an empty RUN [] issue, got: %+v", result.Issues)
+	}
+}
+
+func TestParseDockerfileFlagsDanglingCopyContinuation(t *testing.T) {
+	content := "FROM alpine:3.18\nCOPY foo bar \\\n\nCMD echo done\n"
+	result, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("Failed to parse Dockerfile: %v", err)
+	}
+	if !hasIssueContaining(result.Issues, "dangling line continuation") {
+		t.Errorf("Expected a dangling continuation issue, got: %+v", result.Issues)
+	}
+}
+
+func TestParseDockerfileFlagsUnknownInstruction(t *testing.T) {
+	content := `FROM alpine:3.18
+FROBNICATE something
+`
+	result, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("Failed to parse Dockerfile: %v", err)
+	}
+	if !hasIssueContaining(result.Issues, `unknown instruction "FROBNICATE"`) {
+		t.Errorf("Expected an unknown instruction issue, got: %+v", result.Issues)
+	}
+}
+
+func TestParseDockerfileFlagsUnpinnedBaseImage(t *testing.T) {
+	content := `FROM alpine
+CMD echo done
+`
+	result, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("Failed to parse Dockerfile: %v", err)
+	}
+	if !hasIssueContaining(result.Issues, "not pinned to a version") {
+		t.Errorf("Expected an unpinned base image warning, got: %+v", result.Issues)
+	}
+	for _, issue := range result.Issues {
+		if issue.Severity == SeverityError {
+			t.Errorf("Expected unpinned base image to be a warning, not an error: %+v", issue)
+		}
+	}
+}
+
+func TestLintAndValidateRejectsUnknownInstructionWithoutHadolint(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "docker-lint-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dockerfilePath := filepath.Join(tmpDir, "Dockerfile")
+	content := "FROM alpine:3.18\nFROBNICATE something\n"
+	if err := os.WriteFile(dockerfilePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	plugin := DockerPlugin{}
+	output, err := plugin.LintAndValidate(dockerfilePath)
+	if err == nil {
+		t.Error("Expected an error for an unknown instruction, but got none")
+	}
+	if !strings.Contains(output, "unknown instruction") {
+		t.Errorf("Expected output to mention the unknown instruction, got: %s", output)
+	}
+}
+
+func TestLoadDockerignoreAndIsIgnored(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "docker-ignore-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ignoreContent := "# comment\nnode_modules\n*.log\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".dockerignore"), []byte(ignoreContent), 0644); err != nil {
+		t.Fatalf("Failed to write .dockerignore: %v", err)
+	}
+
+	patterns, err := LoadDockerignore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load .dockerignore: %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Fatalf("Expected 2 patterns, got %d: %v", len(patterns), patterns)
+	}
+
+	if !IsIgnored("node_modules/react/index.js", patterns) {
+		t.Error("Expected a path under node_modules/ to be ignored")
+	}
+	if !IsIgnored("debug.log", patterns) {
+		t.Error("Expected *.log to match debug.log")
+	}
+	if IsIgnored("main.go", patterns) {
+		t.Error("Expected main.go to not be ignored")
+	}
+}
+
+func hasIssueContaining(issues []LintIssue, substr string) bool {
+	for _, issue := range issues {
+		if strings.Contains(issue.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
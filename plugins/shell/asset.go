@@ -5,13 +5,15 @@ import "time"
 
 // Asset holds the metadata for a shell script asset.
 type Asset struct {
-	Generation   int       `json:"generation"`
-	LastRun      time.Time `json:"last_run"`
-	LintPassed   bool      `json:"lint_passed"`
-	TestsPassed  bool      `json:"tests_passed"`
-	Event        string    `json:"event"`
-	User         string    `json:"user"`
-	RunDuration  Duration  `json:"run_duration"`
-	Status       string    `json:"status"`
-	CommitHash   string    `json:"commit_hash"`
+	Generation  int         `json:"generation"`
+	LastRun     time.Time   `json:"last_run"`
+	LintPassed  bool        `json:"lint_passed"`
+	TestsPassed bool        `json:"tests_passed"`
+	Event       string      `json:"event"`
+	User        string      `json:"user"`
+	RunDuration Duration    `json:"run_duration"`
+	MaxRunTime  Duration    `json:"max_run_time"` // Configured timeout for the run that produced this generation, zero meaning none
+	LintSummary LintSummary `json:"lint_summary"` // Shellcheck diagnostic counts/top codes for the run that produced this generation
+	Status      string      `json:"status"`
+	CommitHash  string      `json:"commit_hash"`
 }
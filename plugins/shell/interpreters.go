@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Interpreter describes one scripting language ShellPlugin can lint and
+// execute: Name is both its display name and the PATH lookup key. RunArgs
+// are placed before the script path when executing it. Lintable and
+// ShellcheckDialect describe whether/how shellcheck can check it -
+// shellcheck only understands the POSIX shell family, not zsh or pwsh.
+type Interpreter struct {
+	Name              string
+	RunArgs           []string
+	Lintable          bool
+	ShellcheckDialect string
+}
+
+// defaultInterpreters returns the built-in extension/shebang dispatch
+// table.
+func defaultInterpreters() map[string]Interpreter {
+	return map[string]Interpreter{
+		"bash": {Name: "bash", Lintable: true, ShellcheckDialect: "bash"},
+		"sh":   {Name: "sh", Lintable: true, ShellcheckDialect: "sh"},
+		"zsh":  {Name: "zsh"},
+		"pwsh": {Name: "pwsh", RunArgs: []string{"-File"}},
+	}
+}
+
+// extensionInterpreter maps a file extension to the interpreter name that
+// handles it, used when a script has no (or an unrecognized) shebang.
+var extensionInterpreter = map[string]string{
+	".sh":   "bash",
+	".bash": "bash",
+	".zsh":  "zsh",
+	".ps1":  "pwsh",
+}
+
+// SupportedExtensions returns every file extension (with its leading dot)
+// that has a registered interpreter, e.g. ".sh", ".zsh", ".ps1".
+func SupportedExtensions() []string {
+	exts := make([]string, 0, len(extensionInterpreter))
+	for ext := range extensionInterpreter {
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+// detectInterpreter picks the Interpreter for scriptPath: a recognized
+// shebang takes priority over the file extension, and bash is the fallback
+// when neither matches, preserving this plugin's original behavior.
+func detectInterpreter(interpreters map[string]Interpreter, scriptPath string) Interpreter {
+	if name := shebangInterpreter(scriptPath); name != "" {
+		if interp, ok := interpreters[name]; ok {
+			return interp
+		}
+	}
+	if name, ok := extensionInterpreter[strings.ToLower(filepath.Ext(scriptPath))]; ok {
+		if interp, ok := interpreters[name]; ok {
+			return interp
+		}
+	}
+	return interpreters["bash"]
+}
+
+// shebangInterpreter returns the interpreter name named by scriptPath's
+// shebang line (e.g. "#!/bin/bash" or "#!/usr/bin/env zsh"), or "" if the
+// script has none.
+func shebangInterpreter(scriptPath string) string {
+	f, err := os.Open(scriptPath) // #nosec G304
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return ""
+	}
+	line := scanner.Text()
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	bin := filepath.Base(fields[0])
+	if bin == "env" && len(fields) > 1 {
+		bin = filepath.Base(fields[1])
+	}
+	return bin
+}
@@ -0,0 +1,76 @@
+package shell
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDetectInterpreter_Shebang(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "shebang-*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.WriteString("#!/usr/bin/env zsh\necho hi\n"); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	tmpfile.Close()
+
+	interp := detectInterpreter(defaultInterpreters(), tmpfile.Name())
+	if interp.Name != "zsh" {
+		t.Errorf("Expected shebang to select zsh, got %q", interp.Name)
+	}
+	if interp.Lintable {
+		t.Error("Expected zsh to not be lintable by shellcheck")
+	}
+}
+
+func TestDetectInterpreter_Extension(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "noshebang-*.ps1")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.WriteString("Write-Host hi\n"); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	tmpfile.Close()
+
+	interp := detectInterpreter(defaultInterpreters(), tmpfile.Name())
+	if interp.Name != "pwsh" {
+		t.Errorf("Expected .ps1 extension to select pwsh, got %q", interp.Name)
+	}
+	if len(interp.RunArgs) == 0 || interp.RunArgs[0] != "-File" {
+		t.Errorf("Expected pwsh RunArgs to start with -File, got %v", interp.RunArgs)
+	}
+}
+
+func TestDetectInterpreter_FallsBackToBash(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "plain-*.unknownext")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	interp := detectInterpreter(defaultInterpreters(), tmpfile.Name())
+	if interp.Name != "bash" {
+		t.Errorf("Expected unrecognized extension to fall back to bash, got %q", interp.Name)
+	}
+}
+
+func TestLookPathExcludingCWD_SkipsDotEntries(t *testing.T) {
+	originalPath := os.Getenv("PATH")
+	defer os.Setenv("PATH", originalPath)
+
+	os.Setenv("PATH", ".:"+originalPath)
+	path, err := lookPathExcludingCWD("sh")
+	if err != nil {
+		t.Fatalf("Expected to resolve sh via a real PATH entry, got: %v", err)
+	}
+	if path == "sh" || path == "./sh" {
+		t.Errorf("Expected an absolute path outside the current directory, got %q", path)
+	}
+}
@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// LintReport is a single shellcheck diagnostic.
+type LintReport struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Level   string `json:"level"` // error, warning, info, or style
+	Code    string `json:"code"`  // e.g. "SC2164"
+	Message string `json:"message"`
+}
+
+// LintSummary aggregates a set of LintReports into per-severity counts and
+// the most frequent codes, small enough to persist on an Asset and render
+// as a badge without carrying every diagnostic around.
+type LintSummary struct {
+	Counts   map[string]int `json:"counts"`    // severity -> count
+	TopCodes []string       `json:"top_codes"` // most frequent codes first
+}
+
+// maxTopCodes bounds how many codes SummarizeLintReports keeps in TopCodes.
+const maxTopCodes = 5
+
+// SummarizeLintReports builds a LintSummary from a script's diagnostics.
+func SummarizeLintReports(reports []LintReport) LintSummary {
+	counts := make(map[string]int)
+	codeCounts := make(map[string]int)
+	for _, r := range reports {
+		counts[r.Level]++
+		codeCounts[r.Code]++
+	}
+
+	codes := make([]string, 0, len(codeCounts))
+	for code := range codeCounts {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if codeCounts[codes[i]] != codeCounts[codes[j]] {
+			return codeCounts[codes[i]] > codeCounts[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	if len(codes) > maxTopCodes {
+		codes = codes[:maxTopCodes]
+	}
+
+	return LintSummary{Counts: counts, TopCodes: codes}
+}
+
+// severityRank orders shellcheck's levels from least to most severe, so a
+// MinSeverity gate can compare a diagnostic's level against a threshold.
+var severityRank = map[string]int{
+	"style":   0,
+	"info":    1,
+	"warning": 2,
+	"error":   3,
+}
+
+// meetsMinSeverity reports whether level is at least as severe as min. An
+// empty or unrecognized min disables the gate.
+func meetsMinSeverity(level, min string) bool {
+	minRank, ok := severityRank[min]
+	if !ok {
+		return false
+	}
+	rank, ok := severityRank[level]
+	if !ok {
+		return false
+	}
+	return rank >= minRank
+}
+
+// gatedReports returns the diagnostics at or above min, or nil if min is
+// empty/unrecognized or none qualify.
+func gatedReports(reports []LintReport, min string) []LintReport {
+	if min == "" {
+		return nil
+	}
+	var gated []LintReport
+	for _, r := range reports {
+		if meetsMinSeverity(r.Level, min) {
+			gated = append(gated, r)
+		}
+	}
+	return gated
+}
+
+// shellcheckJSON1 is the subset of shellcheck's `-f json1` output this
+// package parses.
+type shellcheckJSON1 struct {
+	Comments []struct {
+		File    string `json:"file"`
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+		Level   string `json:"level"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"comments"`
+}
+
+// parseShellcheckJSON1 parses shellcheck's `-f json1` output into LintReports.
+func parseShellcheckJSON1(data []byte) ([]LintReport, error) {
+	var parsed shellcheckJSON1
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse shellcheck json1 output: %w", err)
+	}
+	reports := make([]LintReport, 0, len(parsed.Comments))
+	for _, c := range parsed.Comments {
+		reports = append(reports, LintReport{
+			File:    c.File,
+			Line:    c.Line,
+			Column:  c.Column,
+			Level:   c.Level,
+			Code:    fmt.Sprintf("SC%d", c.Code),
+			Message: c.Message,
+		})
+	}
+	return reports, nil
+}
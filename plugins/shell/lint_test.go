@@ -0,0 +1,58 @@
+package shell
+
+import "testing"
+
+func TestParseShellcheckJSON1(t *testing.T) {
+	data := []byte(`{"comments":[
+		{"file":"test.sh","line":2,"column":1,"level":"warning","code":2164,"message":"Use cd ... || exit."},
+		{"file":"test.sh","line":3,"column":1,"level":"info","code":2086,"message":"Double quote to prevent globbing."}
+	]}`)
+
+	reports, err := parseShellcheckJSON1(data)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(reports))
+	}
+	if reports[0].Code != "SC2164" || reports[0].Level != "warning" {
+		t.Errorf("Unexpected first report: %+v", reports[0])
+	}
+	if reports[1].Code != "SC2086" || reports[1].Level != "info" {
+		t.Errorf("Unexpected second report: %+v", reports[1])
+	}
+}
+
+func TestSummarizeLintReports(t *testing.T) {
+	reports := []LintReport{
+		{Level: "warning", Code: "SC2164"},
+		{Level: "warning", Code: "SC2164"},
+		{Level: "info", Code: "SC2086"},
+	}
+
+	summary := SummarizeLintReports(reports)
+	if summary.Counts["warning"] != 2 || summary.Counts["info"] != 1 {
+		t.Errorf("Unexpected counts: %+v", summary.Counts)
+	}
+	if len(summary.TopCodes) == 0 || summary.TopCodes[0] != "SC2164" {
+		t.Errorf("Expected SC2164 to be the top code, got %v", summary.TopCodes)
+	}
+}
+
+func TestMeetsMinSeverity(t *testing.T) {
+	cases := []struct {
+		level, min string
+		want       bool
+	}{
+		{"error", "warning", true},
+		{"warning", "error", false},
+		{"style", "style", true},
+		{"warning", "", false},
+		{"warning", "bogus", false},
+	}
+	for _, c := range cases {
+		if got := meetsMinSeverity(c.level, c.min); got != c.want {
+			t.Errorf("meetsMinSeverity(%q, %q) = %v, want %v", c.level, c.min, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package shell
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+// assetLocks serializes read-modify-write access to a given script's asset
+// within this process: UpdateAsset and UpdateAssetAfterRun both take the
+// same *sync.Mutex for a given script's lock file path for their whole
+// read-modify-write, so two concurrent runs of the same script never race
+// on Generation or interleave their writes. LoadAsset and SaveAsset take no
+// lock when called directly. Keying by the resolved
+// lock path, rather than the bare script name, keeps two repos monitored
+// concurrently that happen to share a script name from serializing on each
+// other's unrelated runs.
+var assetLocks sync.Map // map[string]*sync.Mutex
+
+// withAssetLock holds scriptName's in-process mutex, plus an OS-level
+// advisory lock (flock) on its lock file for cross-process safety, for the
+// duration of fn.
+func (p *ShellPlugin) withAssetLock(scriptName string, fn func() error) error {
+	lockPath, err := p.getAssetLockPath(scriptName)
+	if err != nil {
+		return err
+	}
+
+	muIface, _ := assetLocks.LoadOrStore(lockPath, &sync.Mutex{})
+	mu := muIface.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// #nosec G304
+	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer lockFile.Close()
+
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+
+	return fn()
+}
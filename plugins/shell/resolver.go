@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MissingToolError names an external tool ShellPlugin depends on (bash,
+// shellcheck, sudo, ...) that could not be found on PATH.
+type MissingToolError struct {
+	Tool string
+	Err  error
+}
+
+func (e *MissingToolError) Error() string {
+	return fmt.Sprintf("required tool %q not found in PATH: %v", e.Tool, e.Err)
+}
+
+func (e *MissingToolError) Unwrap() error { return e.Err }
+
+// toolResolver resolves external tool names to absolute paths and caches
+// the result, in the spirit of cli/safeexec: PATH entries equal to "" or
+// "." (the current directory) are skipped entirely, so a hostile working
+// directory containing a same-named binary can never shadow the real one.
+type toolResolver struct {
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+func newToolResolver() *toolResolver {
+	return &toolResolver{cache: make(map[string]string)}
+}
+
+// resolve returns the cached absolute path for name, looking it up on PATH
+// on first use.
+func (r *toolResolver) resolve(name string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if path, ok := r.cache[name]; ok {
+		return path, nil
+	}
+
+	path, err := lookPathExcludingCWD(name)
+	if err != nil {
+		return "", &MissingToolError{Tool: name, Err: err}
+	}
+	r.cache[name] = path
+	return path, nil
+}
+
+// defaultResolver is shared by every ShellPlugin value, since a resolved
+// tool path depends only on the process's PATH, not on any particular
+// plugin instance.
+var defaultResolver = newToolResolver()
+
+// lookPathExcludingCWD searches PATH for name, skipping "" and "." entries,
+// unlike os/exec.LookPath on some platforms.
+func lookPathExcludingCWD(name string) (string, error) {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		return "", fmt.Errorf("%s: tool name must not contain a path separator", name)
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" || dir == "." {
+			continue
+		}
+		candidate := filepath.Join(dir, name)
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+		return candidate, nil
+	}
+	return "", exec.ErrNotFound
+}
@@ -0,0 +1,315 @@
+// Package scripttest is a small integration-test harness for the shell
+// plugin, modeled on Go's own script-driven test engines (cmd/go's
+// internal/vcweb and rsc.io/script): each testdata/*.txtar file declares a
+// script body plus directives describing how it should be linted and run,
+// and the expectations the outcome must satisfy. Run materializes the
+// script to a temp dir, drives it through ShellPlugin.LintAndValidate, Run,
+// and UpdateAssetAfterRun exactly like the real run loop does, and asserts
+// the result - including the persisted Asset - against those expectations.
+package scripttest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/txtar"
+
+	"buenosaires/plugins/shell"
+)
+
+// directives controls one test case, parsed from its txtar comment block.
+type directives struct {
+	env                []string
+	timeout            time.Duration
+	sudo               bool
+	minSeverity        string
+	runs               int
+	wantLintPass       bool
+	wantLintErrSub     string
+	wantRun            bool
+	wantRunStatus      string
+	wantStdout         *regexp.Regexp
+	wantGeneration     int
+	requiresShellcheck bool
+}
+
+// parseDirectives reads one "key value" directive per line from comment,
+// defaulting to the common case (lint passes, the script then runs once).
+func parseDirectives(comment []byte) (directives, error) {
+	d := directives{wantLintPass: true, wantRun: true, runs: 1}
+	scanner := bufio.NewScanner(strings.NewReader(string(comment)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		key := fields[0]
+		value := ""
+		if len(fields) > 1 {
+			value = strings.TrimSpace(fields[1])
+		}
+
+		var err error
+		switch key {
+		case "env":
+			d.env = append(d.env, value)
+		case "timeout":
+			d.timeout, err = time.ParseDuration(value)
+		case "sudo":
+			d.sudo = value == "true"
+		case "min_severity":
+			d.minSeverity = value
+		case "runs":
+			d.runs, err = strconv.Atoi(value)
+		case "want_lint_pass":
+			d.wantLintPass = value == "true"
+		case "want_lint_err_substr":
+			d.wantLintErrSub = value
+		case "want_run":
+			d.wantRun = value == "true"
+		case "want_run_status":
+			d.wantRunStatus = value
+		case "want_stdout_regexp":
+			d.wantStdout, err = regexp.Compile(value)
+		case "want_generation":
+			d.wantGeneration, err = strconv.Atoi(value)
+		case "requires_shellcheck":
+			d.requiresShellcheck = value == "true"
+		default:
+			err = fmt.Errorf("unknown directive %q", key)
+		}
+		if err != nil {
+			return d, fmt.Errorf("%q: %w", line, err)
+		}
+	}
+	return d, scanner.Err()
+}
+
+// goldenAsset is the subset of shell.Asset a golden.json section asserts
+// against. Fields like LastRun and RunDuration are inherently
+// non-deterministic and are deliberately left out.
+type goldenAsset struct {
+	Generation  int    `json:"generation"`
+	LintPassed  bool   `json:"lint_passed"`
+	TestsPassed bool   `json:"tests_passed"`
+	Status      string `json:"status"`
+	CommitHash  string `json:"commit_hash"`
+}
+
+// Run executes every testdata/*.txtar file under dir as a subtest of t. If
+// shellcheck isn't on PATH, it stubs one out so cases that don't rely on
+// real diagnostics (requires_shellcheck unset) still exercise the rest of
+// the harness; cases that do need real diagnostics are skipped individually.
+func Run(t *testing.T, dir string) {
+	t.Helper()
+	realShellcheck := hasRealShellcheck()
+	if !realShellcheck {
+		stubShellcheck(t)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read testdata dir %q: %v", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".txtar") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		name := strings.TrimSuffix(entry.Name(), ".txtar")
+		t.Run(name, func(t *testing.T) {
+			runCase(t, path, realShellcheck)
+		})
+	}
+}
+
+// hasRealShellcheck reports whether a real shellcheck binary is on PATH.
+func hasRealShellcheck() bool {
+	_, err := exec.LookPath("shellcheck")
+	return err == nil
+}
+
+// stubShellcheck prepends a directory containing a no-op "shellcheck" to
+// PATH for the remainder of t's test (and its subtests), so ShellPlugin's
+// startup check and LintAndValidate's shellcheck invocation both succeed
+// with an empty diagnostic set. Restored once t completes.
+func stubShellcheck(t *testing.T) {
+	t.Helper()
+	binDir := t.TempDir()
+	stubPath := filepath.Join(binDir, "shellcheck")
+	stub := "#!/bin/sh\necho '{\"comments\":[]}'\n"
+	if err := os.WriteFile(stubPath, []byte(stub), 0700); err != nil {
+		t.Fatalf("Failed to write shellcheck stub: %v", err)
+	}
+	originalPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+string(os.PathListSeparator)+originalPath)
+	t.Cleanup(func() { os.Setenv("PATH", originalPath) })
+}
+
+// runCase runs a single testdata file's script through the shell plugin and
+// checks the outcome against its directives.
+func runCase(t *testing.T, path string, realShellcheck bool) {
+	t.Helper()
+
+	archive, err := txtar.ParseFile(path)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	d, err := parseDirectives(archive.Comment)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if d.requiresShellcheck && !realShellcheck {
+		t.Skipf("%s: skipping, requires a real shellcheck binary for its diagnostics", path)
+	}
+
+	var scriptData, goldenData []byte
+	for _, f := range archive.Files {
+		switch f.Name {
+		case "script.sh":
+			scriptData = f.Data
+		case "golden.json":
+			goldenData = f.Data
+		default:
+			t.Fatalf("%s: unrecognized txtar section %q", path, f.Name)
+		}
+	}
+	if scriptData == nil {
+		t.Fatalf("%s: missing -- script.sh -- section", path)
+	}
+
+	// ShellPlugin.LoadAsset/SaveAsset and NewAssetStore both resolve
+	// relative to HOME/the working directory, so isolate both per case.
+	tmpDir := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	t.Cleanup(func() { os.Setenv("HOME", originalHome) })
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to chdir into %s: %v", tmpDir, err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+
+	for _, kv := range d.env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			t.Fatalf("%s: invalid env directive %q", path, kv)
+		}
+		os.Setenv(parts[0], parts[1])
+		t.Cleanup(func() { os.Unsetenv(parts[0]) })
+	}
+
+	scriptPath := filepath.Join(tmpDir, "script.sh")
+	if err := os.WriteFile(scriptPath, scriptData, 0700); err != nil {
+		t.Fatalf("%s: failed to write script: %v", path, err)
+	}
+
+	plugin, err := shell.NewShellPlugin()
+	if err != nil {
+		if missing, ok := err.(*shell.MissingToolError); ok {
+			t.Skipf("%s: skipping, %v", path, missing)
+		}
+		t.Fatalf("%s: failed to construct ShellPlugin: %v", path, err)
+	}
+
+	const scriptName = "script.sh"
+	const commitHash = "testcommit"
+	const user = "scripttest"
+
+	var asset shell.Asset
+	for i := 0; i < d.runs; i++ {
+		asset = runOnce(t, path, plugin, scriptPath, scriptName, commitHash, user, d)
+	}
+
+	if goldenData != nil {
+		assertGolden(t, path, asset, goldenData)
+	}
+	if d.wantGeneration != 0 && asset.Generation != d.wantGeneration {
+		t.Errorf("%s: asset generation = %d, want %d", path, asset.Generation, d.wantGeneration)
+	}
+}
+
+// runOnce drives one lint+run+persist cycle, matching the sequence
+// cmd/dispatch.go's shellDispatchPlugin.Process follows for a real commit,
+// and returns the Asset as it stands after that cycle.
+func runOnce(t *testing.T, path string, plugin *shell.ShellPlugin, scriptPath, scriptName, commitHash, user string, d directives) shell.Asset {
+	t.Helper()
+
+	lintOutput, lintReports, lintErr := plugin.LintAndValidate(scriptPath)
+	lintPassed := lintErr == nil
+	lintSummary := shell.SummarizeLintReports(lintReports)
+	if lintPassed != d.wantLintPass {
+		t.Errorf("%s: lint passed = %v, want %v (output: %s)", path, lintPassed, d.wantLintPass, lintOutput)
+	}
+	if d.wantLintErrSub != "" && (lintErr == nil || !strings.Contains(lintErr.Error(), d.wantLintErrSub)) {
+		t.Errorf("%s: expected lint error containing %q, got %v", path, d.wantLintErrSub, lintErr)
+	}
+
+	if !d.wantRun || !lintPassed {
+		if err := plugin.UpdateAssetAfterRun(scriptName, scriptPath, user, commitHash, lintOutput, "", lintPassed, 0, d.timeout, lintSummary, "failure"); err != nil {
+			t.Fatalf("%s: UpdateAssetAfterRun: %v", path, err)
+		}
+	} else {
+		start := time.Now()
+		execOutput, runErr := plugin.Run(context.Background(), scriptPath, shell.RunOptions{
+			AllowSudo:   d.sudo,
+			MaxRunTime:  d.timeout,
+			LintReports: lintReports,
+			MinSeverity: d.minSeverity,
+		})
+		runDuration := time.Since(start)
+		runStatus := shell.RunStatus(runErr)
+
+		if d.wantRunStatus != "" && runStatus != d.wantRunStatus {
+			t.Errorf("%s: run status = %q, want %q (output: %s)", path, runStatus, d.wantRunStatus, execOutput)
+		}
+		if d.wantStdout != nil && !d.wantStdout.MatchString(execOutput) {
+			t.Errorf("%s: output %q does not match %s", path, execOutput, d.wantStdout)
+		}
+
+		if err := plugin.UpdateAssetAfterRun(scriptName, scriptPath, user, commitHash, lintOutput, execOutput, lintPassed, runDuration, d.timeout, lintSummary, runStatus); err != nil {
+			t.Fatalf("%s: UpdateAssetAfterRun: %v", path, err)
+		}
+	}
+
+	asset, err := plugin.LoadAsset(scriptName)
+	if err != nil {
+		t.Fatalf("%s: LoadAsset: %v", path, err)
+	}
+	return asset
+}
+
+// assertGolden compares the fields of asset a golden.json section can
+// meaningfully pin down against the expectations it declares.
+func assertGolden(t *testing.T, path string, asset shell.Asset, goldenData []byte) {
+	t.Helper()
+
+	var want goldenAsset
+	if err := json.Unmarshal(goldenData, &want); err != nil {
+		t.Fatalf("%s: failed to parse golden.json: %v", path, err)
+	}
+	got := goldenAsset{
+		Generation:  asset.Generation,
+		LintPassed:  asset.LintPassed,
+		TestsPassed: asset.TestsPassed,
+		Status:      asset.Status,
+		CommitHash:  asset.CommitHash,
+	}
+	if got != want {
+		t.Errorf("%s: asset = %+v, want %+v", path, got, want)
+	}
+}
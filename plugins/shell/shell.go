@@ -3,33 +3,142 @@
 package shell
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
+	"syscall"
 	"time"
+
+	"buenosaires/internal/config"
+	"buenosaires/plugins/docker"
 )
 
+// ErrTimeout is wrapped into Run's returned error when scriptPath is killed
+// because it exceeded opts.MaxRunTime.
+var ErrTimeout = errors.New("script run timed out")
+
+// ErrCancelled is wrapped into Run's returned error when scriptPath is
+// killed because the caller's context was canceled for a reason other than
+// the MaxRunTime deadline (e.g. the monitor is shutting down).
+var ErrCancelled = errors.New("script run cancelled")
+
+// ErrLintGate is returned by Run, without executing the script, when
+// opts.LintReports contains a diagnostic at or above opts.MinSeverity.
+var ErrLintGate = errors.New("script failed lint severity gate")
+
+// killGrace is how long Run waits after sending SIGTERM to a script's
+// process group before escalating to SIGKILL.
+const killGrace = 5 * time.Second
+
+// RunOptions configures a single ShellPlugin.Run invocation, mirroring the
+// BuildOptions/SandboxOptions convention plugins/docker uses for its own
+// Run/RunSandboxed entry points.
+type RunOptions struct {
+	// AllowSudo runs the script with sudo when sandboxing is disabled.
+	AllowSudo bool
+	// Sandbox, when its Type is "docker", runs the script in an ephemeral
+	// container instead of on the host; a zero value runs on the host.
+	Sandbox config.SandboxConfig
+	// MaxRunTime kills the script's process group past this duration. Zero
+	// means no limit.
+	MaxRunTime time.Duration
+	// LogWriter, if non-nil, receives the script's stdout/stderr as it is
+	// produced, in addition to the buffer Run returns once it completes.
+	LogWriter io.Writer
+	// LintReports is the script's diagnostics from LintAndValidate, checked
+	// against MinSeverity before the script is executed.
+	LintReports []LintReport
+	// MinSeverity refuses to run the script if any LintReports entry is at
+	// or above this severity (error|warning|info|style). Empty disables the
+	// gate.
+	MinSeverity string
+}
+
 // ShellPlugin implements the plugin interface for handling shell scripts.
 // It provides methods for linting/validation and execution.
-type ShellPlugin struct{}
+type ShellPlugin struct {
+	// Interpreters overrides the default shebang/extension dispatch table.
+	// A nil map (the zero value) falls back to defaultInterpreters.
+	Interpreters map[string]Interpreter
+	// RepoPath is the monitored repository this plugin instance is
+	// processing assets for. It namespaces the per-script Asset file, its
+	// advisory lock, and the AssetStore's generation index, so two repos
+	// monitored concurrently that happen to share a script name (e.g.
+	// "deploy.sh") never collide. Empty falls back to the pre-multi-repo
+	// layout rooted at the process's working directory.
+	RepoPath string
+}
+
+// NewShellPlugin constructs a ShellPlugin with the default interpreter
+// table, failing fast with a *MissingToolError if bash or shellcheck - the
+// two tools every deployment needs - aren't on PATH. Other interpreters
+// (zsh, pwsh) and sudo are resolved lazily on first use, so their absence
+// doesn't block startup for repos that never use them.
+func NewShellPlugin() (*ShellPlugin, error) {
+	if _, err := defaultResolver.resolve("bash"); err != nil {
+		return nil, err
+	}
+	if _, err := defaultResolver.resolve("shellcheck"); err != nil {
+		return nil, err
+	}
+	return &ShellPlugin{Interpreters: defaultInterpreters()}, nil
+}
+
+// interpreters returns p.Interpreters, falling back to the built-in table
+// for a zero-value ShellPlugin.
+func (p *ShellPlugin) interpreters() map[string]Interpreter {
+	if p.Interpreters != nil {
+		return p.Interpreters
+	}
+	return defaultInterpreters()
+}
 
 // getAssetPath returns the path to the asset JSON file for a given script.
+// When p.RepoPath is set, the asset lives inside that repository's own
+// .buenosaires directory (mirroring internal/status's per-repo layout), so
+// two repos monitored concurrently never share a script's asset file just
+// because they happen to name it the same. A zero-value ShellPlugin (no
+// RepoPath, used by existing single-repo callers and tests) falls back to
+// the legacy process-relative location.
 func (p *ShellPlugin) getAssetPath(scriptName string) (string, error) {
-	assetsDir := "plugins/shell/assets"
-	if err := os.MkdirAll(assetsDir, 0750); err != nil {
-		return "", err
-	}
 	// Sanitize the script name to prevent directory traversal
 	cleanScriptName := filepath.Clean(scriptName)
 	if cleanScriptName != scriptName || scriptName == ".." || scriptName == "." {
 		return "", fmt.Errorf("invalid script name: %s", scriptName)
 	}
+
+	assetsDir := "plugins/shell/assets"
+	if p.RepoPath != "" {
+		cleanRepoPath := filepath.Clean(p.RepoPath)
+		if cleanRepoPath != p.RepoPath || p.RepoPath == ".." || p.RepoPath == "." {
+			return "", fmt.Errorf("invalid repo path: %s", p.RepoPath)
+		}
+		assetsDir = filepath.Join(cleanRepoPath, ".buenosaires", "shell-assets")
+	}
+	if err := os.MkdirAll(assetsDir, 0750); err != nil {
+		return "", err
+	}
 	return filepath.Join(assetsDir, cleanScriptName+".json"), nil
 }
 
+// getAssetLockPath returns the path to the advisory lock file guarding a
+// given script's asset, alongside its asset JSON file.
+func (p *ShellPlugin) getAssetLockPath(scriptName string) (string, error) {
+	assetPath, err := p.getAssetPath(scriptName)
+	if err != nil {
+		return "", err
+	}
+	return assetPath + ".lock", nil
+}
+
 // LoadAsset loads the asset metadata for a given script.
 func (p *ShellPlugin) LoadAsset(scriptName string) (Asset, error) {
 	var asset Asset
@@ -53,7 +162,9 @@ func (p *ShellPlugin) LoadAsset(scriptName string) (Asset, error) {
 	return asset, nil
 }
 
-// SaveAsset saves the asset metadata for a given script.
+// SaveAsset saves the asset metadata for a given script. The write is
+// atomic: it writes to a temp file in the same directory, then renames it
+// over the target, so a reader never observes a partially written file.
 func (p *ShellPlugin) SaveAsset(scriptName string, asset Asset) error {
 	assetPath, err := p.getAssetPath(scriptName)
 	if err != nil {
@@ -65,90 +176,332 @@ func (p *ShellPlugin) SaveAsset(scriptName string, asset Asset) error {
 		return err
 	}
 
-	return os.WriteFile(assetPath, data, 0600)
+	tmp, err := os.CreateTemp(filepath.Dir(assetPath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), assetPath)
 }
 
-// LintAndValidate performs validation and linting on a shell script.
-// It performs two checks:
-//  1. Syntax validation using bash -n (non-execution mode)
-//  2. Linting with shellcheck to identify potential issues
+// UpdateAsset applies fn to the current asset for scriptName and saves the
+// result, holding scriptName's asset lock for the whole read-modify-write so
+// concurrent callers cannot interleave.
+func (p *ShellPlugin) UpdateAsset(scriptName string, fn func(Asset) Asset) error {
+	return p.withAssetLock(scriptName, func() error {
+		asset, err := p.LoadAsset(scriptName)
+		if err != nil {
+			return err
+		}
+		return p.SaveAsset(scriptName, fn(asset))
+	})
+}
+
+// LintAndValidate performs validation and linting on a script, using the
+// interpreter detected from its shebang line or file extension. It performs
+// up to two checks:
+//  1. Syntax validation in the interpreter's non-execution mode, when it has one
+//  2. Linting with shellcheck, for interpreters shellcheck understands (bash/sh)
 //
-// Returns the combined output from both tools and any errors encountered.
-// Shellcheck warnings (exit code 1) are not treated as fatal errors.
-func (p *ShellPlugin) LintAndValidate(scriptPath string) (string, error) {
+// Returns a human-readable combined log of both tools, the shellcheck
+// diagnostics parsed from its `-f json1` output (nil for non-lintable
+// interpreters), and any error encountered. Shellcheck warnings alone
+// (exit code 1) are not treated as fatal errors - only a syntax failure or
+// a shellcheck invocation failure (exit code > 1) is.
+func (p *ShellPlugin) LintAndValidate(scriptPath string) (string, []LintReport, error) {
+	interp := detectInterpreter(p.interpreters(), scriptPath)
 	var finalOutput bytes.Buffer
 
-	// Step 1: Syntax check using bash in no-execution mode
-	cmdBash := exec.Command("bash", "-n", scriptPath)
-	bashOutput, err := cmdBash.CombinedOutput()
-	finalOutput.Write(bashOutput)
+	bin, err := defaultResolver.resolve(interp.Name)
 	if err != nil {
-		return finalOutput.String(), fmt.Errorf("syntax check failed: %w", err)
+		return "", nil, err
 	}
-	finalOutput.WriteString("Syntax check passed.\n")
 
-	// Step 2: Run shellcheck for static analysis and best practices
-	cmdShellcheck := exec.Command("shellcheck", "-s", "bash", scriptPath)
-	shellcheckOutput, err := cmdShellcheck.CombinedOutput()
-	finalOutput.Write(shellcheckOutput)
+	// Step 1: Syntax check in no-execution mode, for interpreters that have one.
+	if interp.Name == "pwsh" {
+		finalOutput.WriteString("Syntax check skipped: pwsh has no non-execution mode.\n")
+	} else {
+		cmdSyntax := exec.Command(bin, "-n", scriptPath)
+		syntaxOutput, err := cmdSyntax.CombinedOutput()
+		finalOutput.Write(syntaxOutput)
+		if err != nil {
+			return finalOutput.String(), nil, fmt.Errorf("syntax check failed: %w", err)
+		}
+		finalOutput.WriteString("Syntax check passed.\n")
+	}
+
+	// Step 2: Run shellcheck for static analysis and best practices, skipping
+	// interpreters shellcheck doesn't support.
+	if !interp.Lintable {
+		finalOutput.WriteString(fmt.Sprintf("Linting skipped: shellcheck does not support %s.\n", interp.Name))
+		return finalOutput.String(), nil, nil
+	}
 
+	shellcheckBin, err := defaultResolver.resolve("shellcheck")
+	if err != nil {
+		return finalOutput.String(), nil, err
+	}
+	cmdShellcheck := exec.Command(shellcheckBin, "-s", interp.ShellcheckDialect, "-f", "json1", scriptPath)
+	shellcheckOutput, err := cmdShellcheck.Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
-			// Shellcheck returns exit code 1 for warnings (non-fatal)
-			// Only treat exit codes > 1 as fatal errors
+			// Shellcheck returns exit code 1 when it found diagnostics
+			// (non-fatal); only exit codes > 1 mean it couldn't run at all.
 			if exitError.ExitCode() > 1 {
-				return finalOutput.String(), fmt.Errorf("shellcheck failed with exit code %d: %w", exitError.ExitCode(), err)
+				finalOutput.Write(exitError.Stderr)
+				return finalOutput.String(), nil, fmt.Errorf("shellcheck failed with exit code %d: %w", exitError.ExitCode(), err)
 			}
 		} else {
 			// Command execution failed (e.g., shellcheck not installed)
-			return finalOutput.String(), fmt.Errorf("failed to run shellcheck: %w", err)
+			return finalOutput.String(), nil, fmt.Errorf("failed to run shellcheck: %w", err)
+		}
+	}
+
+	reports, parseErr := parseShellcheckJSON1(shellcheckOutput)
+	if parseErr != nil {
+		return finalOutput.String(), nil, parseErr
+	}
+
+	if len(reports) == 0 {
+		finalOutput.WriteString("Linting completed: no diagnostics.\n")
+	} else {
+		for _, r := range reports {
+			fmt.Fprintf(&finalOutput, "%s:%d:%d: %s: %s (%s)\n", r.File, r.Line, r.Column, r.Level, r.Message, r.Code)
 		}
+		finalOutput.WriteString("Linting completed.\n")
 	}
-	finalOutput.WriteString("Linting completed.\n")
 
-	return finalOutput.String(), nil
+	return finalOutput.String(), reports, nil
 }
 
-// Run executes a shell script using bash.
-// Parameters:
-//   - scriptPath: Path to the shell script to execute
-//   - allowSudo: If true, the script is executed with sudo privileges
+// Run executes a script with the interpreter detected from its shebang line
+// or file extension, or - if opts.Sandbox.Type requests it - inside an
+// ephemeral container via the docker plugin. For a host run, the script's
+// process group is killed with SIGTERM (then SIGKILL after killGrace) if ctx
+// is canceled or opts.MaxRunTime elapses, and its stdout/stderr is streamed
+// line-by-line into opts.LogWriter as well as the returned string.
 //
 // Returns the combined stdout and stderr output, and any execution error.
-func (p *ShellPlugin) Run(scriptPath string, allowSudo bool) (string, error) {
+// For a host run, a timeout or cancellation is reported as an error wrapping
+// ErrTimeout or ErrCancelled respectively; a sandboxed run returns
+// docker.RunSandboxed's error as is, which RunStatus classifies as
+// "failure". Pass the error to RunStatus to classify it for persistence.
+func (p *ShellPlugin) Run(ctx context.Context, scriptPath string, opts RunOptions) (string, error) {
+	if gated := gatedReports(opts.LintReports, opts.MinSeverity); len(gated) > 0 {
+		return "", fmt.Errorf("%w: %s:%d: %s (%s)", ErrLintGate, gated[0].File, gated[0].Line, gated[0].Message, gated[0].Code)
+	}
+
+	if opts.Sandbox.Type == "docker" {
+		return p.runSandboxed(ctx, scriptPath, opts.Sandbox)
+	}
+
+	interp := detectInterpreter(p.interpreters(), scriptPath)
+	bin, err := defaultResolver.resolve(interp.Name)
+	if err != nil {
+		return "", err
+	}
+	args := append(append([]string{}, interp.RunArgs...), scriptPath)
+
 	var cmd *exec.Cmd
-	// Execute with or without sudo based on configuration
-	if allowSudo {
-		cmd = exec.Command("sudo", "bash", scriptPath)
+	if opts.AllowSudo {
+		sudoBin, err := defaultResolver.resolve("sudo")
+		if err != nil {
+			return "", err
+		}
+		cmd = exec.Command(sudoBin, append([]string{bin}, args...)...)
 	} else {
-		cmd = exec.Command("bash", scriptPath)
+		cmd = exec.Command(bin, args...)
+	}
+	// Run in its own process group so a timeout/cancellation can kill any
+	// children the script spawned, not just the interpreter itself.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	var output bytes.Buffer
+	var mu sync.Mutex
+	streamTo := func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			mu.Lock()
+			output.WriteString(line)
+			output.WriteByte('\n')
+			if opts.LogWriter != nil {
+				fmt.Fprintln(opts.LogWriter, line)
+			}
+			mu.Unlock()
+		}
 	}
 
-	output, err := cmd.CombinedOutput()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return string(output), err
+		return "", err
+	}
+
+	if opts.MaxRunTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxRunTime)
+		defer cancel()
 	}
-	return string(output), nil
+
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); streamTo(stdout) }()
+	go func() { defer wg.Done(); streamTo(stderr) }()
+
+	waitErr := make(chan error, 1)
+	go func() {
+		wg.Wait()
+		waitErr <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-waitErr:
+		if err != nil {
+			return output.String(), err
+		}
+		return output.String(), nil
+	case <-ctx.Done():
+		pgid := cmd.Process.Pid
+		syscall.Kill(-pgid, syscall.SIGTERM)
+		select {
+		case <-waitErr:
+		case <-time.After(killGrace):
+			syscall.Kill(-pgid, syscall.SIGKILL)
+			<-waitErr
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return output.String(), fmt.Errorf("%w: exceeded %s", ErrTimeout, opts.MaxRunTime)
+		}
+		return output.String(), fmt.Errorf("%w: %w", ErrCancelled, ctx.Err())
+	}
+}
+
+// RunStatus classifies the error Run returned into a short status string
+// suitable for persisting on an Asset: "success", "timeout", "cancelled",
+// "lint-gated" when the lint severity gate refused the run, "signal:<n>"
+// when the process was killed by a signal Run didn't request, or "failure"
+// for any other execution error.
+func RunStatus(err error) string {
+	if err == nil {
+		return "success"
+	}
+	if errors.Is(err, ErrTimeout) {
+		return "timeout"
+	}
+	if errors.Is(err, ErrCancelled) {
+		return "cancelled"
+	}
+	if errors.Is(err, ErrLintGate) {
+		return "lint-gated"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return fmt.Sprintf("signal:%d", status.Signal())
+		}
+	}
+	return "failure"
 }
 
-// UpdateAssetAfterRun updates the asset metadata after a script has been run.
-func (p *ShellPlugin) UpdateAssetAfterRun(scriptName, user, commitHash, event string, lintPassed bool, runDuration time.Duration, runStatus string) error {
-	asset, err := p.LoadAsset(scriptName)
+// runSandboxed runs scriptPath inside an ephemeral container via
+// docker.RunSandboxed, translating sandbox into docker.SandboxOptions and
+// surfacing a non-zero container exit code as an error so callers can treat
+// it the same way as a failed host-run script. ctx is forwarded so the
+// caller's cancellation (a timeout or graceful shutdown) kills the
+// container, not just a host-run process.
+func (p *ShellPlugin) runSandboxed(ctx context.Context, scriptPath string, sandbox config.SandboxConfig) (string, error) {
+	opts := docker.SandboxOptions{
+		Image:       sandbox.Image,
+		Mounts:      sandbox.Mounts,
+		Env:         sandbox.Env,
+		Network:     sandbox.Network,
+		MemoryLimit: sandbox.MemoryLimit,
+		CPULimit:    sandbox.CPULimit,
+	}
+	if sandbox.TimeoutSeconds > 0 {
+		opts.Timeout = time.Duration(sandbox.TimeoutSeconds) * time.Second
+	}
+
+	output, exitCode, err := docker.RunSandboxed(ctx, scriptPath, opts)
 	if err != nil {
-		return err
+		return output, err
+	}
+	if exitCode != 0 {
+		return output, fmt.Errorf("sandboxed script exited with status %d", exitCode)
 	}
+	return output, nil
+}
 
-	asset.Generation++
-	asset.LastRun = time.Now()
-	asset.LintPassed = lintPassed
-	// The shell plugin does not currently support running tests, so this is hardcoded to true.
-	// In the future, this should be updated to reflect the actual test results.
-	asset.TestsPassed = true
-	asset.Event = event
-	asset.User = user
-	asset.RunDuration = Duration{runDuration}
-	asset.Status = runStatus
-	asset.CommitHash = commitHash
-
-	return p.SaveAsset(scriptName, asset)
-}
\ No newline at end of file
+// UpdateAssetAfterRun updates the asset metadata after a script has been
+// run, and records the full run (lint output, execution output, and the
+// script source itself) as a new immutable generation in the
+// content-addressable AssetStore.
+func (p *ShellPlugin) UpdateAssetAfterRun(scriptName, scriptPath, user, commitHash, lintOutput, execOutput string, lintPassed bool, runDuration, maxRunTime time.Duration, lintSummary LintSummary, runStatus string) error {
+	return p.withAssetLock(scriptName, func() error {
+		asset, err := p.LoadAsset(scriptName)
+		if err != nil {
+			return err
+		}
+
+		asset.Generation++
+		asset.LastRun = time.Now()
+		asset.LintPassed = lintPassed
+		// The shell plugin does not currently support running tests, so this is hardcoded to true.
+		// In the future, this should be updated to reflect the actual test results.
+		asset.TestsPassed = true
+		asset.Event = execOutput
+		asset.User = user
+		asset.RunDuration = Duration{runDuration}
+		asset.MaxRunTime = Duration{maxRunTime}
+		asset.LintSummary = lintSummary
+		asset.Status = runStatus
+		asset.CommitHash = commitHash
+
+		if err := p.SaveAsset(scriptName, asset); err != nil {
+			return err
+		}
+
+		store, err := NewAssetStore(p.RepoPath)
+		if err != nil {
+			return err
+		}
+
+		// #nosec G304
+		source, _ := os.ReadFile(scriptPath)
+
+		_, err = store.Put(Run{
+			ScriptName:   scriptName,
+			CommitHash:   commitHash,
+			User:         user,
+			Status:       runStatus,
+			LintPassed:   lintPassed,
+			TestsPassed:  asset.TestsPassed,
+			RunDuration:  runDuration,
+			MaxRunTime:   maxRunTime,
+			LintSummary:  lintSummary,
+			LintOutput:   lintOutput,
+			ExecOutput:   execOutput,
+			ScriptSource: string(source),
+		})
+		return err
+	})
+}
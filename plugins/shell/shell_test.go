@@ -1,12 +1,48 @@
 package shell
 
 import (
+	"context"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"buenosaires/internal/config"
 )
 
+// hasRealShellcheck reports whether a real shellcheck binary is on PATH.
+func hasRealShellcheck() bool {
+	_, err := exec.LookPath("shellcheck")
+	return err == nil
+}
+
+// stubShellcheck prepends a directory containing a no-op "shellcheck" to
+// PATH for the remainder of t's test, so NewShellPlugin's startup check and
+// LintAndValidate's shellcheck invocation both succeed with an empty
+// diagnostic set. Restored once t completes. Mirrors the scripttest
+// package's helper of the same name.
+func stubShellcheck(t *testing.T) {
+	t.Helper()
+	binDir := t.TempDir()
+	stubPath := filepath.Join(binDir, "shellcheck")
+	stub := "#!/bin/sh\necho '{\"comments\":[]}'\n"
+	if err := os.WriteFile(stubPath, []byte(stub), 0700); err != nil {
+		t.Fatalf("Failed to write shellcheck stub: %v", err)
+	}
+	originalPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+string(os.PathListSeparator)+originalPath)
+	t.Cleanup(func() { os.Setenv("PATH", originalPath) })
+}
+
 func TestShellPlugin_LintAndValidate(t *testing.T) {
+	realShellcheck := hasRealShellcheck()
+	if !realShellcheck {
+		stubShellcheck(t)
+	}
+
 	// Test case 1: Valid script
 	validScript := "#!/bin/bash\necho 'hello'"
 	tmpfileValid, err := os.CreateTemp("", "valid-*.sh")
@@ -20,7 +56,7 @@ func TestShellPlugin_LintAndValidate(t *testing.T) {
 	tmpfileValid.Close()
 
 	plugin := ShellPlugin{}
-	output, err := plugin.LintAndValidate(tmpfileValid.Name())
+	output, reports, err := plugin.LintAndValidate(tmpfileValid.Name())
 	if err != nil {
 		t.Errorf("Expected no error for valid script, but got: %v", err)
 	}
@@ -30,6 +66,9 @@ func TestShellPlugin_LintAndValidate(t *testing.T) {
 	if !strings.Contains(output, "Linting completed") {
 		t.Errorf("Expected output to contain 'Linting completed', but got: %s", output)
 	}
+	if len(reports) != 0 {
+		t.Errorf("Expected no diagnostics for a clean script, but got: %+v", reports)
+	}
 
 	// Test case 2: Invalid syntax
 	invalidSyntaxScript := "#!/bin/bash\necho 'hello' &&"
@@ -43,12 +82,16 @@ func TestShellPlugin_LintAndValidate(t *testing.T) {
 	}
 	tmpfileInvalidSyntax.Close()
 
-	_, err = plugin.LintAndValidate(tmpfileInvalidSyntax.Name())
+	_, _, err = plugin.LintAndValidate(tmpfileInvalidSyntax.Name())
 	if err == nil {
 		t.Error("Expected an error for invalid syntax, but got none")
 	}
 
-	// Test case 3: Shellcheck warning (should not fail)
+	// Test case 3: Shellcheck warning (should not fail). The SC2164
+	// diagnostic itself only comes from a real shellcheck; the stub used
+	// when one isn't on PATH always reports a clean "comments": [], so
+	// that assertion is skipped in that case, the same way scripttest
+	// skips cases that need real diagnostics.
 	shellcheckWarningScript := "#!/bin/bash\ncd /tmp\nls"
 	tmpfileShellcheck, err := os.CreateTemp("", "shellcheck-*.sh")
 	if err != nil {
@@ -60,13 +103,28 @@ func TestShellPlugin_LintAndValidate(t *testing.T) {
 	}
 	tmpfileShellcheck.Close()
 
-	output, err = plugin.LintAndValidate(tmpfileShellcheck.Name())
+	output, reports, err = plugin.LintAndValidate(tmpfileShellcheck.Name())
 	if err != nil {
 		t.Errorf("Expected no error for shellcheck warning, but got: %v", err)
 	}
+	if !realShellcheck {
+		t.Skip("skipping SC2164 diagnostic assertions: no real shellcheck on PATH")
+	}
 	if !strings.Contains(output, "SC2164") {
 		t.Errorf("Expected output to contain shellcheck warning 'SC2164', but got: %s", output)
 	}
+	found := false
+	for _, r := range reports {
+		if r.Code == "SC2164" {
+			found = true
+			if r.Level == "" {
+				t.Error("Expected SC2164 diagnostic to have a level")
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a parsed SC2164 diagnostic, but got: %+v", reports)
+	}
 }
 
 func TestShellPlugin_Run(t *testing.T) {
@@ -84,7 +142,7 @@ func TestShellPlugin_Run(t *testing.T) {
 	tmpfile.Close()
 
 	plugin := ShellPlugin{}
-	output, err := plugin.Run(tmpfile.Name(), false)
+	output, err := plugin.Run(context.Background(), tmpfile.Name(), RunOptions{})
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
 	}
@@ -92,4 +150,145 @@ func TestShellPlugin_Run(t *testing.T) {
 	if !strings.Contains(output, "hello") {
 		t.Errorf("Expected output to contain 'hello', but got: %s", output)
 	}
-}
\ No newline at end of file
+}
+
+func TestShellPlugin_Run_Timeout(t *testing.T) {
+	scriptContent := "#!/bin/bash\nsleep 5\necho 'should not print'"
+	tmpfile, err := os.CreateTemp("", "test-timeout-script-*.sh")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.Write([]byte(scriptContent)); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpfile.Close()
+
+	plugin := ShellPlugin{}
+	output, err := plugin.Run(context.Background(), tmpfile.Name(), RunOptions{MaxRunTime: 100 * time.Millisecond})
+	if err == nil {
+		t.Fatal("Expected a timeout error, but got none")
+	}
+	if RunStatus(err) != "timeout" {
+		t.Errorf("Expected RunStatus to classify the error as 'timeout', got %q", RunStatus(err))
+	}
+	if strings.Contains(output, "should not print") {
+		t.Errorf("Expected the script to be killed before it could echo, but got: %s", output)
+	}
+}
+
+func TestShellPlugin_Run_LintGate(t *testing.T) {
+	scriptContent := "#!/bin/bash\necho 'should not run'"
+	tmpfile, err := os.CreateTemp("", "test-gated-script-*.sh")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.Write([]byte(scriptContent)); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpfile.Close()
+
+	plugin := ShellPlugin{}
+	reports := []LintReport{{File: tmpfile.Name(), Line: 2, Level: "warning", Code: "SC2164", Message: "test diagnostic"}}
+
+	_, err = plugin.Run(context.Background(), tmpfile.Name(), RunOptions{LintReports: reports, MinSeverity: "warning"})
+	if err == nil {
+		t.Fatal("Expected the run to be refused by the lint gate, but got no error")
+	}
+	if RunStatus(err) != "lint-gated" {
+		t.Errorf("Expected RunStatus to classify the error as 'lint-gated', got %q", RunStatus(err))
+	}
+
+	output, err := plugin.Run(context.Background(), tmpfile.Name(), RunOptions{LintReports: reports, MinSeverity: "error"})
+	if err != nil {
+		t.Errorf("Expected the run to proceed when no diagnostic meets MinSeverity, but got: %v", err)
+	}
+	if !strings.Contains(output, "should not run") {
+		t.Errorf("Expected the script to have run, but got: %s", output)
+	}
+}
+
+func TestShellPlugin_Run_Sandboxed(t *testing.T) {
+	// Only run this test if Docker is available
+	if !isDockerAvailable() {
+		t.Skip("Docker is not available, skipping sandboxed run test")
+	}
+
+	scriptContent := "#!/bin/sh\necho 'hello from sandbox'"
+	tmpfile, err := os.CreateTemp("", "test-sandbox-script-*.sh")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.Write([]byte(scriptContent)); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpfile.Close()
+
+	plugin := ShellPlugin{}
+	sandbox := config.SandboxConfig{Type: "docker", Image: "alpine:latest", Network: "none"}
+	output, err := plugin.Run(context.Background(), tmpfile.Name(), RunOptions{Sandbox: sandbox})
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+
+	if !strings.Contains(output, "hello from sandbox") {
+		t.Errorf("Expected output to contain 'hello from sandbox', but got: %s", output)
+	}
+}
+
+// isDockerAvailable checks if Docker is available on the system.
+func isDockerAvailable() bool {
+	cmd := exec.Command("docker", "version")
+	return cmd.Run() == nil
+}
+
+func TestShellPlugin_UpdateAssetAfterRun_Concurrent(t *testing.T) {
+	withTempHome(t)
+
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+
+	scriptPath := filepath.Join(tmpDir, "concurrent.sh")
+	if err := os.WriteFile(scriptPath, []byte("#!/bin/bash\necho hi\n"), 0600); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	const n = 20
+	plugin := ShellPlugin{}
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- plugin.UpdateAssetAfterRun("concurrent.sh", scriptPath, "tester", "abc123", "", "hi", true, time.Second, 0, LintSummary{}, "success")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("UpdateAssetAfterRun returned an error: %v", err)
+		}
+	}
+
+	asset, err := plugin.LoadAsset("concurrent.sh")
+	if err != nil {
+		t.Fatalf("Failed to load asset: %v", err)
+	}
+	if asset.Generation != n {
+		t.Errorf("Expected Generation to be %d after %d concurrent runs, got %d", n, n, asset.Generation)
+	}
+}
@@ -0,0 +1,431 @@
+package shell
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"buenosaires/internal/config"
+)
+
+// Run captures everything produced by a single lint+execute pass of a
+// script, before it is written into the content-addressable asset store.
+type Run struct {
+	ScriptName   string
+	CommitHash   string
+	User         string
+	Status       string
+	LintPassed   bool
+	TestsPassed  bool
+	RunDuration  time.Duration
+	MaxRunTime   time.Duration
+	LintSummary  LintSummary
+	LintOutput   string
+	ExecOutput   string
+	TestReport   string
+	ScriptSource string
+}
+
+// Manifest is the per-generation, content-addressed record of a run. It
+// never changes once written - a new run produces a new manifest rather
+// than mutating an existing one - so any past generation can still be
+// inspected or diffed against a later one.
+type Manifest struct {
+	ScriptName  string            `json:"script_name"`
+	Generation  int               `json:"generation"`
+	LastRun     time.Time         `json:"last_run"`
+	CommitHash  string            `json:"commit_hash"`
+	User        string            `json:"user"`
+	Status      string            `json:"status"`
+	LintPassed  bool              `json:"lint_passed"`
+	TestsPassed bool              `json:"tests_passed"`
+	RunDuration Duration          `json:"run_duration"`
+	MaxRunTime  Duration          `json:"max_run_time"`
+	LintSummary LintSummary       `json:"lint_summary"`
+	Blobs       map[string]string `json:"blobs"` // artifact name -> sha256 digest
+}
+
+// scriptIndex tracks which manifest digest backs each generation of a
+// script, so a generation number can be resolved to a manifest without
+// scanning every blob.
+type scriptIndex struct {
+	Generations map[int]string `json:"generations"`
+	Latest      int            `json:"latest"`
+}
+
+// Artifact names stored as blobs in a Manifest.
+const (
+	ArtifactLintOutput   = "lint_output"
+	ArtifactExecOutput   = "exec_output"
+	ArtifactTestReport   = "test_report"
+	ArtifactScriptSource = "script_source"
+)
+
+// AssetStore is an immutable blob+manifest store for shell script run
+// history, rooted at ~/.buenosaires/assets. Blobs are named by the sha256
+// digest of their content, so the same artifact content is never stored
+// twice and existing blobs are never rewritten. Blobs are shared across every
+// monitored repository - that's what makes the dedup effective - but each
+// repository's generation index is kept in its own subdirectory, keyed by
+// repoID, so two repos with a same-named script never resolve or overwrite
+// each other's generations.
+type AssetStore struct {
+	root   string
+	repoID string
+}
+
+// repoID derives a stable, filesystem-safe identifier for repoPath: the
+// sha256 digest of its cleaned form. An empty repoPath (a caller with no
+// specific repository, e.g. a zero-value ShellPlugin) maps to a fixed
+// "default" identifier rather than erroring.
+func repoID(repoPath string) (string, error) {
+	if repoPath == "" {
+		repoPath = "default"
+	} else {
+		cleanRepoPath := filepath.Clean(repoPath)
+		if cleanRepoPath != repoPath || repoPath == ".." || repoPath == "." {
+			return "", fmt.Errorf("invalid repo path: %s", repoPath)
+		}
+	}
+	sum := sha256.Sum256([]byte(repoPath))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// NewAssetStore opens the asset store under the current user's config
+// directory, creating it if it doesn't exist yet, scoped to repoPath's own
+// generation index. repoPath should be the monitored repository's watch
+// path; pass "" only for callers with no specific repository in scope.
+func NewAssetStore(repoPath string) (*AssetStore, error) {
+	id, err := repoID(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Join(configDir, "assets")
+	if err := os.MkdirAll(filepath.Join(root, "blobs"), 0750); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(root, "index", id), 0750); err != nil {
+		return nil, err
+	}
+	return &AssetStore{root: root, repoID: id}, nil
+}
+
+// blobPath returns the on-disk path for a blob digest.
+func (s *AssetStore) blobPath(digest string) string {
+	return filepath.Join(s.root, "blobs", digest)
+}
+
+// putBlob writes content to the store under its sha256 digest and returns
+// that digest. Writing the same content twice is a no-op past the first
+// call - blobs are never rewritten once they exist.
+func (s *AssetStore) putBlob(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	path := s.blobPath(digest)
+	if _, err := os.Stat(path); err == nil {
+		return digest, nil
+	}
+	tmp, err := os.CreateTemp(filepath.Join(s.root, "blobs"), ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// Get returns the raw content of a blob by its digest.
+func (s *AssetStore) Get(digest string) ([]byte, error) {
+	// #nosec G304
+	return os.ReadFile(s.blobPath(digest))
+}
+
+// indexPath returns the path to a script's generation index, within this
+// store instance's own repo-scoped index subdirectory.
+func (s *AssetStore) indexPath(scriptName string) (string, error) {
+	cleanName := filepath.Clean(scriptName)
+	if cleanName != scriptName || scriptName == ".." || scriptName == "." {
+		return "", fmt.Errorf("invalid script name: %s", scriptName)
+	}
+	return filepath.Join(s.root, "index", s.repoID, cleanName+".json"), nil
+}
+
+func (s *AssetStore) loadIndex(scriptName string) (scriptIndex, error) {
+	path, err := s.indexPath(scriptName)
+	if err != nil {
+		return scriptIndex{Generations: make(map[int]string)}, err
+	}
+	return loadIndexAtPath(path)
+}
+
+func (s *AssetStore) saveIndex(scriptName string, idx scriptIndex) error {
+	path, err := s.indexPath(scriptName)
+	if err != nil {
+		return err
+	}
+	return saveIndexAtPath(path, idx)
+}
+
+// loadIndexAtPath reads a scriptIndex directly from path, regardless of
+// which repo's subdirectory it lives in. Used by loadIndex (repo-scoped)
+// and by GC, which must walk every repo's indexes, not just one store
+// instance's own repoID.
+func loadIndexAtPath(path string) (scriptIndex, error) {
+	idx := scriptIndex{Generations: make(map[int]string)}
+	// #nosec G304
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return idx, err
+	}
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return idx, err
+	}
+	if idx.Generations == nil {
+		idx.Generations = make(map[int]string)
+	}
+	return idx, nil
+}
+
+// saveIndexAtPath atomically writes idx to path.
+func saveIndexAtPath(path string, idx scriptIndex) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Put writes a run's artifacts as individual blobs, builds a manifest
+// referencing them, and records it as the next generation for the script.
+// It returns the manifest's own digest.
+func (s *AssetStore) Put(run Run) (string, error) {
+	if run.ScriptName == "" {
+		return "", fmt.Errorf("run must have a script name")
+	}
+
+	idx, err := s.loadIndex(run.ScriptName)
+	if err != nil {
+		return "", err
+	}
+
+	blobs := make(map[string]string)
+	for name, content := range map[string]string{
+		ArtifactLintOutput:   run.LintOutput,
+		ArtifactExecOutput:   run.ExecOutput,
+		ArtifactTestReport:   run.TestReport,
+		ArtifactScriptSource: run.ScriptSource,
+	} {
+		if content == "" {
+			continue
+		}
+		digest, err := s.putBlob([]byte(content))
+		if err != nil {
+			return "", err
+		}
+		blobs[name] = digest
+	}
+
+	generation := idx.Latest + 1
+	manifest := Manifest{
+		ScriptName:  run.ScriptName,
+		Generation:  generation,
+		LastRun:     time.Now(),
+		CommitHash:  run.CommitHash,
+		User:        run.User,
+		Status:      run.Status,
+		LintPassed:  run.LintPassed,
+		TestsPassed: run.TestsPassed,
+		RunDuration: Duration{run.RunDuration},
+		MaxRunTime:  Duration{run.MaxRunTime},
+		LintSummary: run.LintSummary,
+		Blobs:       blobs,
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	digest, err := s.putBlob(manifestData)
+	if err != nil {
+		return "", err
+	}
+
+	idx.Generations[generation] = digest
+	idx.Latest = generation
+	if err := s.saveIndex(run.ScriptName, idx); err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// Resolve looks up the manifest for a script at a specific generation. A
+// generation of 0 resolves to the most recent one.
+func (s *AssetStore) Resolve(scriptName string, generation int) (Manifest, error) {
+	var manifest Manifest
+	idx, err := s.loadIndex(scriptName)
+	if err != nil {
+		return manifest, err
+	}
+	if generation == 0 {
+		generation = idx.Latest
+	}
+	digest, ok := idx.Generations[generation]
+	if !ok {
+		return manifest, fmt.Errorf("no generation %d for script %q", generation, scriptName)
+	}
+	data, err := s.Get(digest)
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, err
+	}
+	return manifest, nil
+}
+
+// History returns every generation recorded for a script, oldest first.
+func (s *AssetStore) History(scriptName string) ([]Manifest, error) {
+	idx, err := s.loadIndex(scriptName)
+	if err != nil {
+		return nil, err
+	}
+	generations := make([]int, 0, len(idx.Generations))
+	for g := range idx.Generations {
+		generations = append(generations, g)
+	}
+	sort.Ints(generations)
+
+	manifests := make([]Manifest, 0, len(generations))
+	for _, g := range generations {
+		manifest, err := s.Resolve(scriptName, g)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// GC prunes all but the most recent `keep` generations of every script in
+// every repo's index, then sweeps any blob no longer referenced by a
+// remaining manifest. It walks the whole store, not just this instance's
+// own repoID subdirectory: blobs are shared across repos, so pruning with
+// only one repo's manifests in view would delete blobs still live for
+// others.
+func (s *AssetStore) GC(keep int) error {
+	if keep < 1 {
+		keep = 1
+	}
+
+	indexDir := filepath.Join(s.root, "index")
+	repoDirs, err := os.ReadDir(indexDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	liveDigests := make(map[string]bool)
+
+	for _, repoDir := range repoDirs {
+		if !repoDir.IsDir() {
+			continue
+		}
+		scriptDir := filepath.Join(indexDir, repoDir.Name())
+		entries, err := os.ReadDir(scriptDir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			scriptPath := filepath.Join(scriptDir, entry.Name())
+			idx, err := loadIndexAtPath(scriptPath)
+			if err != nil {
+				return err
+			}
+
+			generations := make([]int, 0, len(idx.Generations))
+			for g := range idx.Generations {
+				generations = append(generations, g)
+			}
+			sort.Sort(sort.Reverse(sort.IntSlice(generations)))
+
+			pruned := scriptIndex{Generations: make(map[int]string), Latest: idx.Latest}
+			for i, g := range generations {
+				digest := idx.Generations[g]
+				if i >= keep {
+					continue
+				}
+				pruned.Generations[g] = digest
+				liveDigests[digest] = true
+
+				data, err := s.Get(digest)
+				if err != nil {
+					return err
+				}
+				var manifest Manifest
+				if err := json.Unmarshal(data, &manifest); err != nil {
+					return err
+				}
+				for _, blobDigest := range manifest.Blobs {
+					liveDigests[blobDigest] = true
+				}
+			}
+			if err := saveIndexAtPath(scriptPath, pruned); err != nil {
+				return err
+			}
+		}
+	}
+
+	blobDir := filepath.Join(s.root, "blobs")
+	blobEntries, err := os.ReadDir(blobDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range blobEntries {
+		if entry.IsDir() || !liveDigests[entry.Name()] {
+			if err := os.Remove(filepath.Join(blobDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
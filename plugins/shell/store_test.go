@@ -0,0 +1,124 @@
+package shell
+
+import (
+	"os"
+	"testing"
+)
+
+func withTempHome(t *testing.T) {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "store-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+		os.RemoveAll(tmpDir)
+	})
+}
+
+func TestAssetStorePutResolveHistory(t *testing.T) {
+	withTempHome(t)
+
+	store, err := NewAssetStore("")
+	if err != nil {
+		t.Fatalf("Failed to create asset store: %v", err)
+	}
+
+	digest1, err := store.Put(Run{
+		ScriptName:   "deploy.sh",
+		CommitHash:   "abc123",
+		Status:       "success",
+		LintPassed:   true,
+		ExecOutput:   "hello from generation 1",
+		ScriptSource: "#!/bin/bash\necho hello",
+	})
+	if err != nil {
+		t.Fatalf("Failed to put run: %v", err)
+	}
+
+	digest2, err := store.Put(Run{
+		ScriptName:   "deploy.sh",
+		CommitHash:   "def456",
+		Status:       "success",
+		LintPassed:   true,
+		ExecOutput:   "hello from generation 2",
+		ScriptSource: "#!/bin/bash\necho hello world",
+	})
+	if err != nil {
+		t.Fatalf("Failed to put second run: %v", err)
+	}
+	if digest1 == digest2 {
+		t.Error("Expected distinct generations to produce distinct manifest digests")
+	}
+
+	latest, err := store.Resolve("deploy.sh", 0)
+	if err != nil {
+		t.Fatalf("Failed to resolve latest generation: %v", err)
+	}
+	if latest.Generation != 2 || latest.CommitHash != "def456" {
+		t.Errorf("Expected latest generation 2 with commit def456, got %+v", latest)
+	}
+
+	first, err := store.Resolve("deploy.sh", 1)
+	if err != nil {
+		t.Fatalf("Failed to resolve generation 1: %v", err)
+	}
+	if first.CommitHash != "abc123" {
+		t.Errorf("Expected generation 1 to be untouched by the later run, got %+v", first)
+	}
+
+	sourceDigest := first.Blobs[ArtifactScriptSource]
+	source, err := store.Get(sourceDigest)
+	if err != nil {
+		t.Fatalf("Failed to get script source blob: %v", err)
+	}
+	if string(source) != "#!/bin/bash\necho hello" {
+		t.Errorf("Unexpected script source blob content: %s", source)
+	}
+
+	history, err := store.History("deploy.sh")
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 generations of history, got %d", len(history))
+	}
+}
+
+func TestAssetStoreGC(t *testing.T) {
+	withTempHome(t)
+
+	store, err := NewAssetStore("")
+	if err != nil {
+		t.Fatalf("Failed to create asset store: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := store.Put(Run{
+			ScriptName:   "build.sh",
+			CommitHash:   "commit",
+			Status:       "success",
+			ScriptSource: "echo run",
+		}); err != nil {
+			t.Fatalf("Failed to put run %d: %v", i, err)
+		}
+	}
+
+	if err := store.GC(1); err != nil {
+		t.Fatalf("Failed to GC: %v", err)
+	}
+
+	history, err := store.History("build.sh")
+	if err != nil {
+		t.Fatalf("Failed to get history after GC: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected GC to keep only 1 generation, got %d", len(history))
+	}
+	if history[0].Generation != 3 {
+		t.Errorf("Expected the most recent generation (3) to survive GC, got %d", history[0].Generation)
+	}
+}